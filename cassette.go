@@ -0,0 +1,145 @@
+package cc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Cassette is a recording of HTTP exchanges, see RecordCassette and ReplayCassette.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded HTTP exchange.
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest is a recorded HTTP request.
+type CassetteRequest struct {
+	// Method is the HTTP method.
+	Method string `json:"method"`
+	// URL is the path and query of the request, without scheme and host.
+	URL string `json:"url"`
+	// Headers are the scrubbed request headers.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is the scrubbed request body.
+	Body string `json:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded HTTP response.
+type CassetteResponse struct {
+	// StatusCode is the HTTP status code.
+	StatusCode int `json:"status"`
+	// Headers are the scrubbed response headers.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is the scrubbed response body.
+	Body string `json:"body,omitempty"`
+}
+
+// LoadCassette reads a cassette file written by RecordCassette.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("invalid cassette '%s': %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to a file.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// RecordCassette records all HTTP exchanges of the client, including logins, pages of paginated GETs, retried
+// attempts and task polls, to a JSON cassette file, which is valid after every exchange. Secrets are scrubbed as
+// in logs: the X-Auth-Token, Authorization and cookie headers, the token of login responses and the RedactedPaths
+// of payloads. Recording never fails a request, errors writing the file are logged. The file is closed by Close.
+//
+//	client, _ := NewClient("https://cc1.cisco.com", "user", "password", RecordCassette("testdata/sites.json"))
+func RecordCassette(path string) func(*Client) {
+	return func(client *Client) {
+		file := &jsonArrayFile{path: path, prefix: "{\n  \"interactions\": [", suffix: "  ]\n}"}
+		client.HttpClient.Transport = newRecorder(client, file, func(e exchange) any {
+			return Interaction{
+				Request: CassetteRequest{
+					Method:  e.req.Method,
+					URL:     e.req.URL.RequestURI(),
+					Headers: redactHeaders(e.req.Header),
+					Body:    e.reqBody,
+				},
+				Response: CassetteResponse{
+					StatusCode: e.res.StatusCode,
+					Headers:    redactHeaders(e.res.Header),
+					Body:       e.resBody,
+				},
+			}
+		})
+	}
+}
+
+// ReplayCassette serves the HTTP exchanges of the cassette instead of sending requests. A request is answered
+// with the first unused interaction of the same method and URL, so repeated requests, e.g. task polls, are
+// answered in recording order. Requests without such interaction fail. Use it with any client URL, e.g.
+//
+//	cassette, err := LoadCassette("testdata/sites.json")
+//	client, _ := NewClient("https://cc1.cisco.com", "user", "password", ReplayCassette(cassette))
+func ReplayCassette(cassette *Cassette) func(*Client) {
+	return func(client *Client) {
+		client.HttpClient.Transport = &cassettePlayer{cassette: cassette, used: make([]bool, len(cassette.Interactions))}
+	}
+}
+
+// cassettePlayer is the transport of ReplayCassette.
+type cassettePlayer struct {
+	cassette *Cassette
+	mu       sync.Mutex
+	used     []bool
+}
+
+// RoundTrip implements http.RoundTripper.
+func (p *cassettePlayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, interaction := range p.cassette.Interactions {
+		if p.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != req.URL.RequestURI() {
+			continue
+		}
+		p.used[i] = true
+		header := make(http.Header, len(interaction.Response.Headers))
+		for k, v := range interaction.Response.Headers {
+			header.Set(k, v)
+		}
+		// Scrubbing may have changed the length of the body.
+		header.Del("Content-Length")
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction left for %s %s", req.Method, req.URL.RequestURI())
+}
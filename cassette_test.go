@@ -0,0 +1,82 @@
+package cc
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// TestCassette tests recording and replaying a cassette.
+func TestCassette(t *testing.T) {
+	defer gock.Off()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	client := testClient()
+	RedactPaths("password")(client)
+	RecordCassette(path)(client)
+
+	gock.New(testURL).Post("/dna/system/api/v1/auth/token").Reply(200).BodyString(`{"Token": "secret"}`)
+	gock.New(testURL).Get("/url").MatchParam("offset", "3").Reply(200).BodyString(`{"response": [3]}`)
+	gock.New(testURL).Get("/url").Reply(200).BodyString(`{"response": [1, 2], "totalCount": 3}`)
+	gock.New(testURL).Post("/url").Reply(202).BodyString(`{"response": {"taskId": "123"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"progress": "running"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"progress": "done", "endTime": 1700000001000}}`)
+
+	run := func(client *Client) (Res, Res, error) {
+		if err := client.Login(); err != nil {
+			return Res{}, Res{}, err
+		}
+		pages, err := client.Get("/url", PageSize(2))
+		if err != nil {
+			return Res{}, Res{}, err
+		}
+		task, err := client.Post("/url", `{"name": "a", "password": "pw"}`)
+		return pages, task, err
+	}
+	recordedPages, recordedTask, err := run(client)
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+	gock.Off()
+
+	cassette, err := LoadCassette(path)
+	assert.NoError(t, err)
+	if assert.Len(t, cassette.Interactions, 6) {
+		login := cassette.Interactions[0]
+		assert.Equal(t, "/dna/system/api/v1/auth/token", login.Request.URL)
+		assert.Equal(t, "REDACTED", login.Request.Headers["Authorization"])
+		assert.JSONEq(t, `{"Token": "REDACTED"}`, login.Response.Body)
+		assert.Equal(t, "/url?limit=2&offset=3", cassette.Interactions[2].Request.URL)
+		assert.Equal(t, "REDACTED", cassette.Interactions[3].Request.Headers["X-Auth-Token"])
+		assert.JSONEq(t, `{"name": "a", "password": "REDACTED"}`, cassette.Interactions[3].Request.Body)
+	}
+
+	replay, _ := NewClient("https://cc.example.com", "usr", "pwd", MaxRetries(0), ReplayCassette(cassette))
+	pages, task, err := run(replay)
+	assert.NoError(t, err)
+	assert.Equal(t, recordedPages.Raw, pages.Raw)
+	assert.Equal(t, recordedTask.Raw, task.Raw)
+	_, err = replay.Get("/url")
+	assert.ErrorContains(t, err, "no recorded interaction left for GET /url")
+}
+
+// TestRecordCassetteErrors tests that recording neither fails nor repeats requests and keeps Insecure working.
+func TestRecordCassetteErrors(t *testing.T) {
+	defer gock.Off()
+	client, _ := NewClient(testURL, "usr", "pwd", MaxRetries(3),
+		RecordCassette(filepath.Join(t.TempDir(), "missing", "cassette.json")), Insecure(false))
+	tr, ok := baseTransport(client.HttpClient.Transport)
+	assert.True(t, ok)
+	assert.False(t, tr.TLSClientConfig.InsecureSkipVerify)
+
+	gock.InterceptClient(client.HttpClient)
+	RecordCassette(filepath.Join(t.TempDir(), "missing", "cassette.json"))(client)
+	client.SetToken("ABC")
+	gock.New(testURL).Post("/url").Times(1).Reply(200).BodyString(`{"response": "ok"}`)
+	gock.New(testURL).Post("/url").Reply(500)
+
+	res, err := client.Post("/url", `{}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Get("response").String())
+	assert.Len(t, gock.Pending(), 1)
+}
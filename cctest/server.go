@@ -0,0 +1,371 @@
+// Package cctest provides an in-process fake Catalyst Center for testing code which uses the cc client.
+//
+// The fake serves the token endpoint, the task and execution status endpoints with scriptable lifecycles,
+// offset paginated collections, token expiry and rate limiting, e.g.
+//
+//	srv := cctest.NewServer()
+//	defer srv.Close()
+//	srv.RespondTask("POST", "/dna/intent/api/v1/site", "T1")
+//	srv.AddTask("T1", cctest.TaskState{Progress: "running"}, cctest.TaskState{Progress: "done", Done: true})
+//
+//	client, _ := srv.NewClient()
+//	res, err := client.Post("/dna/intent/api/v1/site", "{}")
+package cctest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	cc "github.com/netascode/go-catalystcenter"
+)
+
+const (
+	// DefaultUsername is the username accepted by a new Server.
+	DefaultUsername = "admin"
+	// DefaultPassword is the password accepted by a new Server.
+	DefaultPassword = "password"
+	// DefaultPageSize is the maximum number of items returned per page of a collection.
+	DefaultPageSize = 500
+)
+
+// TaskState is the state of a task returned by one poll of /api/v1/task/{id}.
+type TaskState struct {
+	// Progress is the progress message.
+	Progress string
+	// Data is the data attribute of the task.
+	Data string
+	// Done sets the endTime attribute, which marks the task as completed.
+	Done bool
+	// IsError marks the task as failed, it implies Done.
+	IsError bool
+	// FailureReason is the failure reason of a failed task.
+	FailureReason string
+}
+
+// ExecutionState is the state of an execution returned by one poll of
+// /dna/platform/management/business-api/v1/execution-status/{id}.
+type ExecutionState struct {
+	// Status is one of "IN_PROGRESS", "SUCCESS" or "FAILURE".
+	Status string
+	// BapiError is the error message of a failed execution.
+	BapiError string
+}
+
+// Request is a request received by the Server.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   string
+}
+
+type response struct {
+	status int
+	body   string
+}
+
+// Server is a fake Catalyst Center. Always use NewServer to construct it.
+type Server struct {
+	*httptest.Server
+	// Username is the username accepted by the token endpoint.
+	Username string
+	// Password is the password accepted by the token endpoint.
+	Password string
+	// PageSize is the maximum number of items returned per page of a collection.
+	PageSize int
+
+	mu          sync.Mutex
+	tokenCount  int
+	tokens      map[string]bool
+	tasks       map[string][]TaskState
+	executions  map[string][]ExecutionState
+	responses   map[string][]response
+	collections map[string][]json.RawMessage
+	rateLimited int
+	retryAfter  int
+	requests    []Request
+}
+
+// NewServer starts a new fake Catalyst Center. The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		Username:    DefaultUsername,
+		Password:    DefaultPassword,
+		PageSize:    DefaultPageSize,
+		tokens:      make(map[string]bool),
+		tasks:       make(map[string][]TaskState),
+		executions:  make(map[string][]ExecutionState),
+		responses:   make(map[string][]response),
+		collections: make(map[string][]json.RawMessage),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /dna/system/api/v1/auth/token", s.handleToken)
+	mux.HandleFunc("GET /api/v1/task/{id}", s.authorized(s.handleTask))
+	mux.HandleFunc("GET /dna/platform/management/business-api/v1/execution-status/{id}", s.authorized(s.handleExecution))
+	mux.HandleFunc("/", s.authorized(s.handleDefault))
+	s.Server = httptest.NewServer(s.record(mux))
+	return s
+}
+
+// NewClient creates a new cc client for this server, authenticating with the Username and Password of the server.
+func (s *Server) NewClient(mods ...func(*cc.Client)) (*cc.Client, error) {
+	return cc.NewClient(s.URL, s.Username, s.Password, mods...)
+}
+
+// Respond registers a static response for the method and path, e.g. Respond("GET", "/dna/intent/api/v1/site", 200, `{"response":[]}`).
+// Multiple responses for the same method and path are returned in order, the last one is repeated.
+func (s *Server) Respond(method, path string, status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := method + " " + path
+	s.responses[key] = append(s.responses[key], response{status: status, body: body})
+}
+
+// RespondTask registers a response for the method and path which starts the asynchronous task with the given ID.
+func (s *Server) RespondTask(method, path, id string) {
+	s.Respond(method, path, 202, fmt.Sprintf(`{"response":{"taskId":%q,"url":"/api/v1/task/%s"},"version":"1.0"}`, id, id))
+}
+
+// RespondExecution registers a response for the method and path which starts the asynchronous execution with the given ID.
+func (s *Server) RespondExecution(method, path, id string) {
+	s.Respond(method, path, 202, fmt.Sprintf(`{"executionId":%q,"executionStatusUrl":"/dna/platform/management/business-api/v1/execution-status/%s"}`, id, id))
+}
+
+// AddTask scripts the lifecycle of a task. Every poll returns the next state, the last state is repeated.
+func (s *Server) AddTask(id string, states ...TaskState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks[id] = append(s.tasks[id], states...)
+}
+
+// AddExecution scripts the lifecycle of an execution. Every poll returns the next state, the last state is repeated.
+func (s *Server) AddExecution(id string, states ...ExecutionState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executions[id] = append(s.executions[id], states...)
+}
+
+// AddCollection registers a GET path returning the items in a "response" array, paginated with the 1-based "offset"
+// and the "limit" query parameters. Items are marshalled to JSON, json.RawMessage can be used for raw JSON.
+func (s *Server) AddCollection(path string, items ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.collections[path] == nil {
+		s.collections[path] = []json.RawMessage{}
+	}
+	for _, item := range items {
+		raw, err := json.Marshal(item)
+		if err != nil {
+			panic(err)
+		}
+		s.collections[path] = append(s.collections[path], raw)
+	}
+}
+
+// ExpireTokens invalidates all issued tokens, so that the next requests receive a 401 response.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]bool)
+}
+
+// RateLimit makes the next n requests, except for the token endpoint, fail with 429 and the given Retry-After seconds.
+func (s *Server) RateLimit(n, retryAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimited = n
+	s.retryAfter = retryAfter
+}
+
+// Requests returns all requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Logins returns the number of successful logins so far.
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenCount
+}
+
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Header: r.Header.Clone(), Body: string(body)})
+		s.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		valid := s.tokens[r.Header.Get("X-Auth-Token")]
+		limited := s.rateLimited > 0
+		if limited {
+			s.rateLimited--
+		}
+		retryAfter := s.retryAfter
+		s.mu.Unlock()
+
+		if !valid {
+			writeJSON(w, http.StatusUnauthorized, `{"error":"Unauthorized"}`)
+			return
+		}
+		if limited {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			writeJSON(w, http.StatusTooManyRequests, `{"error":"Too Many Requests"}`)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	usr, pwd, ok := r.BasicAuth()
+	if !ok || usr != s.Username || pwd != s.Password {
+		writeJSON(w, http.StatusUnauthorized, `{"error":"Authentication has failed. Please provide valid credentials."}`)
+		return
+	}
+
+	s.mu.Lock()
+	s.tokenCount++
+	token := fmt.Sprintf("token-%d", s.tokenCount)
+	s.tokens[token] = true
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, fmt.Sprintf(`{"Token":%q}`, token))
+}
+
+func (s *Server) handleTask(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	states := s.tasks[id]
+	var state TaskState
+	if len(states) > 0 {
+		state = states[0]
+		if len(states) > 1 {
+			s.tasks[id] = states[1:]
+		}
+	}
+	s.mu.Unlock()
+
+	if len(states) == 0 {
+		writeJSON(w, http.StatusNotFound, fmt.Sprintf(`{"response":{"errorCode":"Not found","message":"Task %s not found"}}`, id))
+		return
+	}
+
+	task := map[string]any{
+		"taskId":    id,
+		"progress":  state.Progress,
+		"isError":   state.IsError,
+		"startTime": time.Now().UnixMilli(),
+	}
+	if state.Done || state.IsError {
+		task["endTime"] = time.Now().UnixMilli()
+	}
+	if state.Data != "" {
+		task["data"] = state.Data
+	}
+	if state.FailureReason != "" {
+		task["failureReason"] = state.FailureReason
+	}
+	body, _ := json.Marshal(map[string]any{"response": task, "version": "1.0"})
+	writeJSON(w, http.StatusOK, string(body))
+}
+
+func (s *Server) handleExecution(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mu.Lock()
+	states := s.executions[id]
+	var state ExecutionState
+	if len(states) > 0 {
+		state = states[0]
+		if len(states) > 1 {
+			s.executions[id] = states[1:]
+		}
+	}
+	s.mu.Unlock()
+
+	if len(states) == 0 {
+		writeJSON(w, http.StatusNotFound, fmt.Sprintf(`{"message":"Execution %s not found"}`, id))
+		return
+	}
+
+	execution := map[string]any{
+		"executionId": id,
+		"status":      state.Status,
+		"startTime":   time.Now().UnixMilli(),
+	}
+	if state.Status != "IN_PROGRESS" {
+		execution["endTime"] = time.Now().UnixMilli()
+	}
+	if state.BapiError != "" {
+		execution["bapiError"] = state.BapiError
+	}
+	body, _ := json.Marshal(execution)
+	writeJSON(w, http.StatusOK, string(body))
+}
+
+func (s *Server) handleDefault(w http.ResponseWriter, r *http.Request) {
+	key := r.Method + " " + r.URL.Path
+	s.mu.Lock()
+	responses := s.responses[key]
+	var res response
+	if len(responses) > 0 {
+		res = responses[0]
+		if len(responses) > 1 {
+			s.responses[key] = responses[1:]
+		}
+	}
+	items, isCollection := s.collections[r.URL.Path]
+	pageSize := s.PageSize
+	s.mu.Unlock()
+
+	if len(responses) > 0 {
+		writeJSON(w, res.status, res.body)
+		return
+	}
+	if isCollection && r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, page(items, r, pageSize))
+		return
+	}
+	writeJSON(w, http.StatusNotFound, `{"response":{"errorCode":"NCND01001","message":"Not found"}}`)
+}
+
+// page returns the JSON of the page of items selected by the offset and limit query parameters.
+func page(items []json.RawMessage, r *http.Request, pageSize int) string {
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 1 {
+		offset = 1
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 || limit > pageSize {
+		limit = pageSize
+	}
+
+	start := min(offset-1, len(items))
+	end := min(start+limit, len(items))
+	body, _ := json.Marshal(map[string]any{"response": items[start:end], "version": "1.0"})
+	return string(body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, body)
+}
@@ -0,0 +1,126 @@
+package cctest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	cc "github.com/netascode/go-catalystcenter"
+)
+
+// TestServerPagination tests a paginated collection.
+func TestServerPagination(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.PageSize = 2
+	srv.AddCollection("/dna/intent/api/v1/network-device", map[string]string{"id": "1"}, map[string]string{"id": "2"}, map[string]string{"id": "3"})
+
+	client, _ := srv.NewClient()
+	res, err := client.Get("/dna/intent/api/v1/network-device", cc.PageSize(2))
+	assert.NoError(t, err)
+	assert.Equal(t, `["1","2","3"]`, res.Get("response.#.id").Raw)
+	assert.Equal(t, 1, srv.Logins())
+}
+
+// TestServerTask tests the lifecycle of a task.
+func TestServerTask(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.RespondTask("POST", "/dna/intent/api/v1/site", "T1")
+	srv.AddTask("T1", TaskState{Progress: "running"}, TaskState{Progress: "done", Done: true})
+	srv.RespondTask("POST", "/dna/intent/api/v1/site", "T2")
+	srv.AddTask("T2", TaskState{Progress: "running"}, TaskState{IsError: true, FailureReason: "bad"})
+
+	client, _ := srv.NewClient()
+	res, err := client.Post("/dna/intent/api/v1/site", "{}")
+	assert.NoError(t, err)
+	assert.Equal(t, "done", res.Get("response.progress").String())
+
+	_, err = client.Post("/dna/intent/api/v1/site", "{}")
+	var taskErr *cc.TaskError
+	if assert.True(t, errors.As(err, &taskErr)) {
+		assert.Equal(t, "T2", taskErr.TaskID)
+		assert.Equal(t, "bad", taskErr.FailureReason)
+	}
+}
+
+// TestServerExecution tests the lifecycle of an execution.
+func TestServerExecution(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.RespondExecution("POST", "/dna/intent/api/v1/global-pool", "E1")
+	srv.AddExecution("E1", ExecutionState{Status: "IN_PROGRESS"}, ExecutionState{Status: "SUCCESS"})
+
+	client, _ := srv.NewClient()
+	res, err := client.Post("/dna/intent/api/v1/global-pool", "{}")
+	assert.NoError(t, err)
+	assert.Equal(t, "SUCCESS", res.Get("status").String())
+}
+
+// TestServerTokenExpiry tests the re-authentication after the token expired.
+func TestServerTokenExpiry(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Respond("GET", "/url", 200, `{"response":"ok"}`)
+
+	client, _ := srv.NewClient()
+	_, err := client.Get("/url")
+	assert.NoError(t, err)
+
+	srv.ExpireTokens()
+	res, err := client.Get("/url")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Get("response").String())
+	assert.Equal(t, 2, srv.Logins())
+}
+
+// TestServerRateLimit tests the retry after a 429 response.
+func TestServerRateLimit(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Respond("GET", "/url", 200, `{"response":"ok"}`)
+
+	client, _ := srv.NewClient(cc.MaxRetries(1), cc.BackoffMinDelay(0), cc.BackoffMaxDelay(0))
+	srv.RateLimit(1, 0)
+	res, err := client.Get("/url")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Get("response").String())
+
+	var limited int
+	for _, r := range srv.Requests() {
+		if r.Path == "/url" {
+			limited++
+		}
+	}
+	assert.Equal(t, 2, limited)
+}
+
+// TestServerConcurrent tests concurrent requests sharing a client while the token expires. Run it with -race.
+func TestServerConcurrent(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddCollection("/url", 1, 2, 3)
+	srv.Respond("POST", "/url", 200, `{"response":"ok"}`)
+
+	client, _ := srv.NewClient()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := client.Get("/url")
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := client.Post("/url", "{}")
+			assert.NoError(t, err)
+		}()
+		if i%10 == 0 {
+			srv.ExpireTokens()
+		}
+	}
+	wg.Wait()
+}
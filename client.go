@@ -3,13 +3,12 @@ package cc
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
-	"log"
-	"math"
-	"math/rand"
+	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
 	"strings"
@@ -27,20 +26,19 @@ const DefaultBackoffDelayFactor float64 = 3
 const DefaultDefaultMaxAsyncWaitTime int = 30
 const MaxAttempts int = 50
 
+// SynchronousApiEndpoints are the endpoints executed synchronously by default, see RegisterSyncEndpoint.
 var SynchronousApiEndpoints = [...]string{
 	"/dna/intent/api/v1/site",
 	"/dna/intent/api/v1/global-pool",
 }
 
-// Client is an HTTP Catalyst Center client.
+// Client is an HTTP Catalyst Center client. It is safe for concurrent use by multiple goroutines.
 // Always use NewClient to construct it, otherwise requests will panic.
 type Client struct {
 	// HttpClient is the *http.Client used for API requests.
 	HttpClient *http.Client
 	// Url is the Catalyst Center IP or hostname, e.g. https://10.0.0.1:443 (port is optional).
 	Url string
-	// Token is the current authentication token
-	Token string
 	// Usr is the Catalyst Center username.
 	Usr string
 	// Pwd is the Catalyst Center password.
@@ -55,10 +53,36 @@ type Client struct {
 	BackoffDelayFactor float64
 	// Maximum async operations wait time
 	DefaultMaxAsyncWaitTime int
+	// PollPolicy defines how asynchronous operations are polled
+	PollPolicy PollPolicy
+	// RetryPolicy decides about retries of failed requests, nil means DefaultRetryPolicy
+	RetryPolicy RetryPolicy
+	// Logger is the structured logger, nil means logging to the standard log package
+	Logger *slog.Logger
+	// RedactedPaths are GJSON paths of payload values which are redacted in logs
+	RedactedPaths []string
+	// TokenLifetime is the assumed lifetime of a token without an expiry claim
+	TokenLifetime time.Duration
+	// TokenRefreshMargin is how long before its expiry a token is refreshed
+	TokenRefreshMargin time.Duration
+	token              *tokenState
 	// Authentication mutex ensures that API login is non-concurrent
 	AuthenticationMutex *sync.Mutex
-	readers             chan int
-	writers             chan int
+	// LockScope maps a request path to the scope of the reader/writer gate, nil means a single scope
+	LockScope  func(path string) string
+	gates      map[string]*gate
+	gatesMutex *sync.Mutex
+	gateStats  *gateStats
+	// rateLimiters pace the requests, see WithRateLimit.
+	rateLimiters []*rateLimiter
+	// tracer and metrics instrument the client, see WithTracer and WithMetricsSink.
+	tracer  Tracer
+	metrics MetricsSink
+	// middleware wraps the requests made with Do, see Use.
+	middleware []func(next Handler) Handler
+	// ctx is canceled when the client is closed.
+	ctx    context.Context
+	cancel context.CancelFunc
 	// writingMutex protects against concurrent DELETE/POST/PUT requests towards the API.
 	writingMutex *sync.Mutex
 }
@@ -67,7 +91,7 @@ type Client struct {
 // Pass modifiers in to modify the behavior of the client, e.g.
 //
 //	client, _ := NewClient("https://cc1.cisco.com", "user", "password", RequestTimeout(120))
-func NewClient(url, usr, pwd string, mods ...func(*Client)) (Client, error) {
+func NewClient(url, usr, pwd string, mods ...func(*Client)) (*Client, error) {
 	tr := http.DefaultTransport.(*http.Transport).Clone()
 	tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 
@@ -81,7 +105,7 @@ func NewClient(url, usr, pwd string, mods ...func(*Client)) (Client, error) {
 	// Remove trailing slash from base URL to prevent double slashes in the final request URL
 	url = strings.TrimSuffix(url, "/")
 
-	client := Client{
+	client := &Client{
 		HttpClient:              &httpClient,
 		Url:                     url,
 		Usr:                     usr,
@@ -91,42 +115,70 @@ func NewClient(url, usr, pwd string, mods ...func(*Client)) (Client, error) {
 		BackoffMaxDelay:         DefaultBackoffMaxDelay,
 		BackoffDelayFactor:      DefaultBackoffDelayFactor,
 		DefaultMaxAsyncWaitTime: DefaultDefaultMaxAsyncWaitTime,
+		PollPolicy:              DefaultPollPolicy,
+		TokenLifetime:           DefaultTokenLifetime,
+		TokenRefreshMargin:      DefaultTokenRefreshMargin,
 		AuthenticationMutex:     &sync.Mutex{},
-		readers:                 make(chan int),
-		writers:                 make(chan int),
+		token:                   &tokenState{},
+		gates:                   make(map[string]*gate),
+		gatesMutex:              &sync.Mutex{},
+		gateStats:               &gateStats{},
 		writingMutex:            &sync.Mutex{},
+		ctx:                     context.Background(),
 	}
 
-	go func() {
-		var readerNum, writerNum int
+	for _, mod := range mods {
+		mod(client)
+	}
 
-		for {
-			select {
-			case delta := <-client.readers:
-				readerNum += delta
-				for readerNum != 0 {
-					readerNum += <-client.readers
-				}
+	client.ctx, client.cancel = context.WithCancel(client.ctx)
+	context.AfterFunc(client.ctx, client.HttpClient.CloseIdleConnections)
+	return client, nil
+}
 
-			case delta := <-client.writers:
-				writerNum += delta
-				for writerNum != 0 {
-					writerNum += <-client.writers
-				}
-			}
-		}
-	}()
+// WithContext binds the client to the context. When the context is done, the client is closed.
+func WithContext(ctx context.Context) func(*Client) {
+	return func(client *Client) {
+		client.ctx = ctx
+	}
+}
 
-	for _, mod := range mods {
-		mod(&client)
+// Close shuts down the client. In-flight requests are canceled, subsequent requests fail with ErrClientClosed
+// and idle connections are closed. Calling Close multiple times is safe.
+func (client *Client) Close() error {
+	client.cancel()
+	return nil
+}
+
+// bind derives a context which is also canceled when the client is closed.
+func (client *Client) bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(client.ctx, func() { cancel(ErrClientClosed) })
+	return ctx, func() {
+		stop()
+		cancel(nil)
 	}
-	return client, nil
+}
+
+// closedErr replaces the cancellation error of a context canceled by Close with ErrClientClosed.
+func closedErr(ctx context.Context, err error) error {
+	if err != nil && errors.Is(err, context.Canceled) && errors.Is(context.Cause(ctx), ErrClientClosed) {
+		return ErrClientClosed
+	}
+	return err
 }
 
 // Insecure determines if insecure https connections are allowed. Default value is true.
 func Insecure(x bool) func(*Client) {
 	return func(client *Client) {
-		client.HttpClient.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = x
+		tr, ok := baseTransport(client.HttpClient.Transport)
+		if !ok {
+			return
+		}
+		if tr.TLSClientConfig == nil {
+			tr.TLSClientConfig = &tls.Config{}
+		}
+		tr.TLSClientConfig.InsecureSkipVerify = x
 	}
 }
 
@@ -173,21 +225,22 @@ func DefaultMaxAsyncWaitTime(x int) func(*Client) {
 }
 
 // NewReq creates a new Req request for this client.
-func (client Client) NewReq(method, uri string, body io.Reader, mods ...func(*Req)) Req {
+func (client *Client) NewReq(method, uri string, body io.Reader, mods ...func(*Req)) Req {
 	httpReq, _ := http.NewRequest(method, client.Url+uri, body)
 	req := Req{
 		HttpReq:          httpReq,
 		LogPayload:       true,
 		Synchronous:      true,
 		MaxAsyncWaitTime: client.DefaultMaxAsyncWaitTime,
+		PollPolicy:       client.PollPolicy,
 		NoWait:           false,
 		ReAuthAttempted:  false,
 	}
 	for _, mod := range mods {
 		mod(&req)
 	}
-	if req.Synchronous && contains(SynchronousApiEndpoints[:], uri) && contains([]string{"POST", "PUT", "DELETE"}, strings.ToUpper(method)) {
-		req.HttpReq.Header.Add("__runsync", "true")
+	if req.Synchronous && isSyncEndpoint(uri) && contains([]string{"POST", "PUT", "DELETE"}, strings.ToUpper(method)) {
+		req.setSync()
 	}
 	return req
 }
@@ -198,6 +251,28 @@ func (client Client) NewReq(method, uri string, body io.Reader, mods ...func(*Re
 //	req := client.NewReq("GET", "/dna/intent/api/v2/site", nil)
 //	res, _ := client.Do(req)
 func (client *Client) Do(req Req) (Res, error) {
+	return client.DoCtx(context.Background(), req)
+}
+
+// DoCtx is like Do, but the context bounds the HTTP requests, the backoff and rate limit sleeps
+// as well as waiting for the asynchronous task.
+func (client *Client) DoCtx(ctx context.Context, req Req) (Res, error) {
+	if client.ctx.Err() != nil {
+		return Res{}, ErrClientClosed
+	}
+	ctx, cancel := client.bind(ctx)
+	defer cancel()
+	ctx, end := client.telemetry().startRequest(ctx, &req)
+	response, err := client.handler()(ctx, &req)
+	err = closedErr(ctx, err)
+	end(err)
+	return response.Res, err
+}
+
+// do implements DoCtx.
+// The status code of the last HTTP response is stored in statusCode.
+func (client *Client) do(ctx context.Context, req Req, statusCode *int) (Res, error) {
+	req.HttpReq = req.HttpReq.WithContext(ctx)
 	// add token
 	req.HttpReq.Header.Add("Content-Type", "application/json")
 	// retain the request body across multiple attempts
@@ -207,9 +282,11 @@ func (client *Client) Do(req Req) (Res, error) {
 	}
 
 	var res Res
+	policy := client.retryPolicy(&req)
+	logger := client.logger().With("method", req.HttpReq.Method, "url", req.HttpReq.URL.String())
 
 	defer func() {
-		log.Printf("[DEBUG] Exit from Do method: %s, %s", req.HttpReq.Method, req.HttpReq.URL)
+		logger.DebugContext(ctx, "Exit from Do method")
 	}()
 
 	if req.HttpReq.Method == "DELETE" || req.HttpReq.Method == "POST" || req.HttpReq.Method == "PUT" {
@@ -218,95 +295,112 @@ func (client *Client) Do(req Req) (Res, error) {
 			defer client.writingMutex.Unlock()
 		}
 
-		client.writers <- +1
-		defer func() { client.writers <- -1 }()
+		leave, err := client.enter(ctx, req.HttpReq.URL.Path, gateWriter)
+		if err != nil {
+			return Res{}, err
+		}
+		defer leave()
 	}
 
 	for attempts := 0; ; attempts++ {
-		req.HttpReq.Header.Set("X-Auth-Token", client.Token)
+		// Refresh the token before it expires, so that the request is not replayed after a 401.
+		if client.tokenExpiring() {
+			if err := client.AuthenticateCtx(ctx); err != nil {
+				return Res{}, err
+			}
+		}
+		token := client.Token()
+		req.HttpReq.Header.Set("X-Auth-Token", token)
 		req.HttpReq.Body = io.NopCloser(bytes.NewBuffer(body))
-		if req.LogPayload {
-			log.Printf("[DEBUG] HTTP Request: %s, %s, %s", req.HttpReq.Method, req.HttpReq.URL, req.HttpReq.Body)
+		req.HttpReq.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		if err := client.rateLimit(ctx, req.HttpReq.URL.Path); err != nil {
+			return Res{}, err
+		}
+		if req.LogPayload && logger.Enabled(ctx, slog.LevelDebug) {
+			logger.DebugContext(ctx, "HTTP Request", "attempt", attempts, "headers", redactHeaders(req.HttpReq.Header), "payload", client.redactPayload(string(body)))
 		} else {
-			log.Printf("[DEBUG] HTTP Request: %s, %s", req.HttpReq.Method, req.HttpReq.URL)
+			logger.DebugContext(ctx, "HTTP Request", "attempt", attempts, "headers", redactHeaders(req.HttpReq.Header))
 		}
 
+		start := time.Now()
+		attemptCtx, span := client.telemetry().startAttempt(ctx, &req, attempts)
+		req.HttpReq = req.HttpReq.WithContext(attemptCtx)
 		httpRes, err := client.HttpClient.Do(req.HttpReq)
-		if err != nil {
-			if ok := client.Backoff(attempts); !ok {
-				log.Printf("[ERROR] HTTP Connection error occured: %+v", err)
-				return Res{}, err
-			} else {
-				log.Printf("[ERROR] HTTP Connection failed: %s, retries: %v", err, attempts)
-				continue
+		var attemptStatusCode int
+		if err == nil {
+			defer httpRes.Body.Close()
+			attemptStatusCode = httpRes.StatusCode
+			*statusCode = attemptStatusCode
+			var bodyBytes []byte
+			bodyBytes, err = io.ReadAll(httpRes.Body)
+			if err != nil {
+				logger.ErrorContext(ctx, "Cannot decode response body", "attempt", attempts, "error", err)
 			}
+			res = Res(gjson.ParseBytes(bodyBytes))
+		} else {
+			logger.ErrorContext(ctx, "HTTP Connection error occured", "attempt", attempts, "error", err)
 		}
-
-		defer httpRes.Body.Close()
-		bodyBytes, err := io.ReadAll(httpRes.Body)
+		client.telemetry().endAttempt(ctx, span, &req, attemptStatusCode, err)
 		if err != nil {
-			if ok := client.Backoff(attempts); !ok {
-				log.Printf("[ERROR] Cannot decode response body: %+v", err)
+			if ctx.Err() != nil {
+				return Res{}, ctx.Err()
+			}
+			retry, delay := policy.Retry(&req, attempts, httpRes, Res{}, err)
+			if !retry {
+				return Res{}, err
+			}
+			logger.WarnContext(ctx, "Retrying HTTP Request", "attempt", attempts, "delay", delay)
+			if err := client.telemetry().backoff(ctx, &req, delay); err != nil {
 				return Res{}, err
-			} else {
-				log.Printf("[ERROR] Cannot decode response body: %s, retries: %v", err, attempts)
-				continue
 			}
+			continue
 		}
-		res = Res(gjson.ParseBytes(bodyBytes))
-		if req.LogPayload {
-			log.Printf("[DEBUG] HTTP Response: %s", res.Raw)
+		if req.LogPayload && logger.Enabled(ctx, slog.LevelDebug) {
+			logger.DebugContext(ctx, "HTTP Response", "attempt", attempts, "status", httpRes.StatusCode, "duration", time.Since(start), "payload", client.redactPayload(res.Raw))
+		} else {
+			logger.DebugContext(ctx, "HTTP Response", "attempt", attempts, "status", httpRes.StatusCode, "duration", time.Since(start))
 		}
 
 		if httpRes.StatusCode >= 200 && httpRes.StatusCode <= 299 {
 			break
 		} else if httpRes.StatusCode == 401 {
 			if req.ReAuthAttempted {
-				log.Printf("[ERROR] Original request failed with 401 even after re-authentication. Returning 401.")
-				return res, fmt.Errorf("HTTP Request failed: StatusCode %v (after re-authentication)", httpRes.StatusCode)
+				logger.ErrorContext(ctx, "Original request failed with 401 even after re-authentication", "status", httpRes.StatusCode)
+				return res, &HTTPError{StatusCode: httpRes.StatusCode, Method: req.HttpReq.Method, URL: req.HttpReq.URL.String(), Body: res, ReAuthenticated: true}
 			}
 
-			log.Printf("[WARNING] Received 401 Unauthorized. Attempting to re-authenticate.")
+			logger.WarnContext(ctx, "Received 401 Unauthorized, attempting to re-authenticate", "status", httpRes.StatusCode)
+			client.telemetry().metrics.ReAuthenticated(ctx, "unauthorized")
 			req.ReAuthAttempted = true
 
-			client.Token = ""
-			authErr := client.Authenticate()
+			client.invalidateToken(token)
+			authErr := client.AuthenticateCtx(ctx)
 			if authErr != nil {
-				log.Printf("[ERROR] Re-authentication failed: %v. Original request failed with 401.", authErr)
+				logger.ErrorContext(ctx, "Re-authentication failed", "error", authErr)
 				return res, fmt.Errorf("authentication failed after 401: %w", authErr)
 			}
 
-			log.Printf("[INFO] Re-authentication successful. Retrying original request.")
+			logger.InfoContext(ctx, "Re-authentication successful, retrying original request")
 			continue
+		}
+
+		retry, delay := policy.Retry(&req, attempts, httpRes, res, nil)
+		if !retry {
+			logger.ErrorContext(ctx, "HTTP Request failed", "attempt", attempts, "status", httpRes.StatusCode)
+			return res, &HTTPError{StatusCode: httpRes.StatusCode, Method: req.HttpReq.Method, URL: req.HttpReq.URL.String(), Body: res}
+		}
+		if httpRes.StatusCode == 429 {
+			logger.WarnContext(ctx, "HTTP Request rate limited", "attempt", attempts, "status", httpRes.StatusCode, "delay", delay)
 		} else {
-			if ok := client.Backoff(attempts); !ok {
-				log.Printf("[ERROR] HTTP Request failed: StatusCode %v", httpRes.StatusCode)
-				return res, fmt.Errorf("HTTP Request failed: StatusCode %v", httpRes.StatusCode)
-			} else if httpRes.StatusCode == 429 {
-				retryAfter := httpRes.Header.Get("Retry-After")
-				retryAfterDuration := time.Duration(0)
-				if retryAfter == "0" {
-					retryAfterDuration = time.Second
-				} else if retryAfter != "" {
-					retryAfterDuration, _ = time.ParseDuration(retryAfter + "s")
-				} else {
-					retryAfterDuration = 15 * time.Second
-				}
-				log.Printf("[WARNING] HTTP Request rate limited, waiting %v seconds, Retries: %v", retryAfterDuration.Seconds(), attempts)
-				time.Sleep(retryAfterDuration)
-				continue
-			} else if httpRes.StatusCode == 408 || (httpRes.StatusCode >= 502 && httpRes.StatusCode <= 504) {
-				log.Printf("[ERROR] HTTP Request failed: StatusCode %v, Retries: %v", httpRes.StatusCode, attempts)
-				continue
-			} else {
-				log.Printf("[ERROR] HTTP Request failed: StatusCode %v", httpRes.StatusCode)
-				return res, fmt.Errorf("HTTP Request failed: StatusCode %v", httpRes.StatusCode)
-			}
+			logger.ErrorContext(ctx, "HTTP Request failed, retrying", "attempt", attempts, "status", httpRes.StatusCode, "delay", delay)
+		}
+		if err := client.telemetry().backoff(ctx, &req, delay); err != nil {
+			return res, err
 		}
 	}
 
 	if !req.NoWait && req.Synchronous && req.HttpReq.Method != "GET" && req.HttpReq.Method != "" {
-		return client.WaitTask(&req, &res)
+		return client.waitTask(ctx, &req, &res)
 	}
 
 	return res, nil
@@ -314,190 +408,281 @@ func (client *Client) Do(req Req) (Res, error) {
 
 // WaitTask waits for an asynchronous task to complete.
 func (client *Client) WaitTask(req *Req, res *Res) (Res, error) {
-	var asyncOp, id string
-	if res.Get("response.taskId").Exists() {
-		asyncOp = "task"
-		id = res.Get("response.taskId").String()
-	} else if res.Get("executionId").Exists() {
-		asyncOp = "execution"
-		id = res.Get("executionId").String()
-	}
-	if asyncOp != "" {
-		startTime := time.Now()
-		reAuthAttempted := false
-		for attempts := 0; attempts <= MaxAttempts; attempts++ {
-			sleep := 0.5 * float64(attempts)
-			if sleep > 2 {
-				sleep = 2
-			}
-			time.Sleep(time.Duration(sleep * float64(time.Second)))
-			var taskReq *http.Request
-			if asyncOp == "task" {
-				taskReq, _ = http.NewRequest("GET", client.Url+"/api/v1/task/"+id, nil)
-			} else {
-				taskReq, _ = http.NewRequest("GET", client.Url+"/dna/platform/management/business-api/v1/execution-status/"+id, nil)
-			}
-			taskReq.Header.Set("X-Auth-Token", client.Token)
-			httpTaskRes, err := client.HttpClient.Do(taskReq)
-			if err != nil {
+	return client.WaitTaskCtx(context.Background(), req, res)
+}
+
+// WaitTaskCtx is like WaitTask, but stops polling as soon as the context is done.
+func (client *Client) WaitTaskCtx(ctx context.Context, req *Req, res *Res) (Res, error) {
+	if client.ctx.Err() != nil {
+		return Res{}, ErrClientClosed
+	}
+	ctx, cancel := client.bind(ctx)
+	defer cancel()
+	taskRes, err := client.waitTask(ctx, req, res)
+	return taskRes, closedErr(ctx, err)
+}
+
+// waitTask implements WaitTaskCtx.
+func (client *Client) waitTask(ctx context.Context, req *Req, res *Res) (Res, error) {
+	if handle, ok := NewTaskHandle(*res); ok {
+		return client.waitTaskID(ctx, req, handle.Kind, handle.ID)
+	}
+	return *res, nil
+}
+
+// waitTaskID polls the status of the task or execution id until it completes.
+func (client *Client) waitTaskID(ctx context.Context, req *Req, kind TaskKind, id string) (Res, error) {
+	ctx, end := client.telemetry().startTask(ctx, kind, id)
+	res, err := client.pollTask(ctx, req, kind, id)
+	end(err)
+	return res, err
+}
+
+// pollTask implements waitTaskID.
+func (client *Client) pollTask(ctx context.Context, req *Req, asyncOp TaskKind, id string) (Res, error) {
+	logger := client.logger().With("task_id", id)
+	p := newPoller(req)
+	reAuthAttempted := false
+	for attempts := 0; ; attempts++ {
+		if err := p.wait(ctx); err != nil {
+			return Res{}, err
+		}
+		var taskReq *http.Request
+		if asyncOp == TaskKindTask {
+			taskReq, _ = http.NewRequestWithContext(ctx, "GET", client.Url+"/api/v1/task/"+id, nil)
+		} else {
+			taskReq, _ = http.NewRequestWithContext(ctx, "GET", client.Url+"/dna/platform/management/business-api/v1/execution-status/"+id, nil)
+		}
+		if client.tokenExpiring() {
+			if err := client.AuthenticateCtx(ctx); err != nil {
 				return Res{}, err
 			}
-			defer httpTaskRes.Body.Close()
-
-			// Handle 401 Unauthorized - reauth token
-			if httpTaskRes.StatusCode == 401 {
-				if reAuthAttempted {
-					log.Printf("[ERROR] Task status check failed with 401 even after re-authentication. Returning 401.")
-					return Res{}, fmt.Errorf("task status check failed: StatusCode 401 (after re-authentication)")
-				}
-
-				log.Printf("[WARNING] Task status check received 401 Unauthorized. Attempting to re-authenticate.")
-				reAuthAttempted = true
-
-				client.Token = ""
-				authErr := client.Authenticate()
-				if authErr != nil {
-					log.Printf("[ERROR] Re-authentication failed: %v. Task status check failed with 401.", authErr)
-					return Res{}, fmt.Errorf("authentication failed after 401: %w", authErr)
-				}
-
-				log.Printf("[INFO] Re-authentication successful. Retrying task status check.")
-				attempts-- // Don't count re-auth as a polling attempt
-				continue
+		}
+		token := client.Token()
+		taskReq.Header.Set("X-Auth-Token", token)
+		if err := client.rateLimit(ctx, taskReq.URL.Path); err != nil {
+			return Res{}, err
+		}
+		pollCtx, span := client.telemetry().startPoll(ctx, asyncOp, id, attempts)
+		httpTaskRes, err := client.HttpClient.Do(taskReq.WithContext(pollCtx))
+		if err != nil {
+			span.End(err)
+			return Res{}, err
+		}
+		defer httpTaskRes.Body.Close()
+		span.SetAttributes(Attr{"http.response.status_code", httpTaskRes.StatusCode})
+		span.End(nil)
+
+		// Handle 401 Unauthorized - reauth token
+		if httpTaskRes.StatusCode == 401 {
+			if reAuthAttempted {
+				logger.ErrorContext(ctx, "Task status check failed with 401 even after re-authentication", "status", httpTaskRes.StatusCode)
+				return Res{}, &HTTPError{StatusCode: httpTaskRes.StatusCode, Method: taskReq.Method, URL: taskReq.URL.String(), ReAuthenticated: true}
 			}
 
-			taskBodyBytes, err := io.ReadAll(httpTaskRes.Body)
-			if err != nil {
-				log.Printf("[ERROR] Cannot decode response body: %+v", err)
-				log.Printf("[DEBUG] Exit from Do method")
-				return Res{}, err
+			logger.WarnContext(ctx, "Task status check received 401 Unauthorized, attempting to re-authenticate", "status", httpTaskRes.StatusCode)
+			client.telemetry().metrics.ReAuthenticated(ctx, "unauthorized")
+			reAuthAttempted = true
+
+			client.invalidateToken(token)
+			authErr := client.AuthenticateCtx(ctx)
+			if authErr != nil {
+				logger.ErrorContext(ctx, "Re-authentication failed", "error", authErr)
+				return Res{}, fmt.Errorf("authentication failed after 401: %w", authErr)
 			}
-			taskRes := Res(gjson.ParseBytes(taskBodyBytes))
-			log.Printf("[DEBUG] task response %v", taskRes.String())
 
-			// Reset re-auth flag on successful response
-			reAuthAttempted = false
+			logger.InfoContext(ctx, "Re-authentication successful, retrying task status check")
+			continue
+		}
 
-			if taskRes.Get("response.isError").Bool() {
-				log.Printf("[ERROR] Task '%s' failed: %s, %s", id, taskRes.Get("response.progress").String(), taskRes.Get("response.failureReason").String())
-				log.Printf("[DEBUG] Exit from Do method")
-				return taskRes, fmt.Errorf("task '%s' failed: %s, %s", id, taskRes.Get("response.progress").String(), taskRes.Get("response.failureReason").String())
-			}
-			if !taskRes.Get("response.isError").Bool() && taskRes.Get("response.endTime").Exists() {
-				log.Printf("[DEBUG] Exit from Do method")
-				return taskRes, nil
-			}
-			if taskRes.Get("status").String() == "FAILURE" {
-				log.Printf("[ERROR] Task '%s' failed: %s", id, taskRes.Get("bapiError").String())
-				log.Printf("[DEBUG] Exit from Do method")
-				return taskRes, fmt.Errorf("task '%s' failed: %s", id, taskRes.Get("bapiError").String())
-			}
-			if taskRes.Get("status").String() == "SUCCESS" {
-				log.Printf("[DEBUG] Exit from Do method")
-				return taskRes, nil
-			}
-			log.Printf("[DEBUG] Waiting for task '%s' to complete.", id)
-			if time.Since(startTime) > time.Duration(req.MaxAsyncWaitTime)*time.Second {
-				log.Printf("[DEBUG] Maximum waiting time reached for task '%s'.", id)
-				return taskRes, fmt.Errorf("maximum waiting time for task '%s' reached", id)
+		taskBodyBytes, err := io.ReadAll(httpTaskRes.Body)
+		if err != nil {
+			logger.ErrorContext(ctx, "Cannot decode response body", "error", err)
+			return Res{}, err
+		}
+		taskRes := Res(gjson.ParseBytes(taskBodyBytes))
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			logger.DebugContext(ctx, "Task response", "attempt", attempts, "status", httpTaskRes.StatusCode, "duration", p.elapsed(), "payload", client.redactPayload(taskRes.Raw))
+		}
+
+		// Reset re-auth flag on successful response
+		reAuthAttempted = false
+
+		if req.OnTaskProgress != nil {
+			req.OnTaskProgress(newTaskStatus(asyncOp, id, taskRes))
+		}
+
+		if taskRes.Get("response.isError").Bool() {
+			logger.ErrorContext(ctx, "Task failed", "progress", taskRes.Get("response.progress").String(), "failure_reason", taskRes.Get("response.failureReason").String(), "duration", p.elapsed())
+			return taskRes, &TaskError{TaskID: id, Progress: taskRes.Get("response.progress").String(), FailureReason: taskRes.Get("response.failureReason").String(), Res: taskRes}
+		}
+		if !taskRes.Get("response.isError").Bool() && taskRes.Get("response.endTime").Exists() {
+			if req.WaitTaskTree {
+				return client.waitTaskTree(ctx, req, id, p, taskRes)
 			}
+			return taskRes, nil
+		}
+		if taskRes.Get("status").String() == "FAILURE" {
+			logger.ErrorContext(ctx, "Task failed", "failure_reason", taskRes.Get("bapiError").String(), "duration", p.elapsed())
+			return taskRes, &TaskError{TaskID: id, FailureReason: taskRes.Get("bapiError").String(), Res: taskRes}
+		}
+		if taskRes.Get("status").String() == "SUCCESS" {
+			return taskRes, nil
+		}
+		logger.DebugContext(ctx, "Waiting for task to complete", "attempt", attempts)
+		if p.expired() {
+			logger.DebugContext(ctx, "Maximum waiting time reached for task", "duration", p.elapsed())
+			return taskRes, &TimeoutError{TaskID: id, Timeout: p.timeout, Res: taskRes}
 		}
 	}
-	return *res, nil
 }
 
 var maxItems = 500
 
 // Get makes a GET request and returns a gjson result.
-// Before GET is issued, the func ensures that no writing (DELETE/POST/PUT) would run concurrently with it
-// on the entire client (on any path).
 //
 // Results will be the raw data structure as returned by Catalyst Center, except when it contains an array named
-// "response" with 500 items. In that case the func continues with more GET requests until it can return a concatenation
-// of all the retrieved items from all the pages.
+// "response" with a full page of items (500 by default, see PageSize and Pagination). In that case the func continues
+// with more GET requests until it can return a concatenation of all the retrieved items from all the pages.
 //
+// The first page is requested optimistically, without waiting for writers. Only if it is a full page,
+// the func waits for writers and from then on ensures that no writing (DELETE/POST/PUT) runs concurrently with it
+// on the entire client (on any path), or within the lock scope of the path if WithLockScope is used.
 // With multiple GETs, the concurrency protection is uninterrupted from the first page until the last page.
 // Protection from concurrent POST or DELETE helps against boundary items being shifted between the pages.
 // Protection from concurrent PUT helps against items moving between pages, when sort becomes unstable due to
 // modification of items.
 // Unfortunately, the protection does not cover any requests from other clients/processes/systems.
 func (client *Client) Get(path string, mods ...func(*Req)) (Res, error) {
-	// This channel operation will wait for any writers to complete first.
-	// Improvement idea: optimistic GET without any waiting. But then if it returns 500 items,
-	// throw its result away, wait for lock, restart with pagination?
-	client.readers <- +1
-	defer func() { client.readers <- -1 }()
+	return client.GetCtx(context.Background(), path, mods...)
+}
+
+// GetCtx is like Get, but the context bounds all the page requests.
+func (client *Client) GetCtx(ctx context.Context, path string, mods ...func(*Req)) (Res, error) {
+	opts := client.NewReq("GET", path, nil, mods...)
+	p := newPagination(opts, path)
+
+	// The first page is requested optimistically without waiting for any writers. Only if there are more pages,
+	// wait for the writers to complete first. The first page is reused, unless a writer may have modified it.
+	g, _ := client.gate(path)
+	generation, writing := g.generation()
+	first, err := client.getPage(ctx, p.path(path, true), mods...)
+	if err != nil {
+		return first, err
+	}
+	response := first.Get("response")
+	if !response.IsArray() {
+		return first, nil
+	}
+	if items := len(response.Array()); !p.advance(first, items, items) {
+		return first, nil
+	}
+
+	leave, err := client.enter(ctx, path, gateReader)
+	if err != nil {
+		return Res{}, err
+	}
+	defer leave()
+	if current, _ := g.generation(); writing || current != generation {
+		first = Res{}
+	}
 
-	offset := 1
+	for restarts := 0; ; restarts++ {
+		res, err := client.gatherPages(ctx, path, mods, first, opts)
+		if !errors.Is(err, ErrInconsistentPages) || restarts >= maxPageRestarts {
+			return res, err
+		}
+		client.logger().WarnContext(ctx, "Restarting pagination", "url", client.Url+path, "attempt", restarts, "error", err)
+		first = Res{}
+	}
+}
+
+// maxPageRestarts is the maximum number of times Get restarts the pagination after inconsistent pages.
+const maxPageRestarts = 3
+
+// gatherPages concatenates all pages into a single result. If VerifyPagesKey is set, the consistency of the pages is verified.
+func (client *Client) gatherPages(ctx context.Context, path string, mods []func(*Req), first Res, opts Req) (Res, error) {
 	gather := gatherer{}
 	gather.WriteByte('[')
+	var last Res
+	var verifier *pageVerifier
+	if opts.VerifyPagesKey != "" {
+		verifier = newPageVerifier(opts.VerifyPagesKey)
+	}
+	pages, received := 0, 0
 
-	for {
-		raw, err := client.get(pathWithOffset(path, offset), mods...)
+	for raw, err := range client.pageIter(ctx, path, mods, first, opts) {
 		if err != nil {
 			return raw, err
 		}
 
 		response := raw.Get("response")
-		if !response.Exists() {
-			return raw, err
-		}
-
 		if !response.IsArray() {
-			if offset != 1 {
-				return gjson.Parse("null"), fmt.Errorf("expected `response` to be an array, but got: %s", response.Type)
-			}
-			return raw, err
+			return raw, nil
 		}
 
 		items := response.Array()
+		if verifier != nil {
+			if err := verifier.verify(raw, items); err != nil {
+				return gjson.Parse("null"), err
+			}
+		}
+		pages++
+		received += len(items)
+		last = raw
+		gather.GatherJSON(items, ',')
+	}
 
-		if len(items) != maxItems {
-			if offset == 1 {
-				return raw, err
-			} else {
-				gather.Grow(len(raw.Raw) - 1) // hot path optimization
-				gather.GatherJSON(items, ',')
-				gather.WriteByte(']')
-
-				s, err := sjson.SetRawBytes([]byte(raw.Raw), "response", gather.Bytes())
-				if err != nil {
-					return gjson.Parse("null"), err
-				}
+	if verifier != nil {
+		if err := verifier.done(received); err != nil {
+			return gjson.Parse("null"), err
+		}
+	}
 
-				log.Printf("[DEBUG] All GET pages combined: %s", s)
+	if pages == 1 {
+		return last, nil
+	}
 
-				return gjson.ParseBytes(s), nil
-			}
-		}
+	gather.WriteByte(']')
+	s, err := sjson.SetRawBytes([]byte(last.Raw), "response", gather.Bytes())
+	if err != nil {
+		return gjson.Parse("null"), err
+	}
 
-		gather.GatherJSON(items, ',')
-		offset += len(items)
+	if logger := client.logger(); logger.Enabled(ctx, slog.LevelDebug) {
+		logger.DebugContext(ctx, "All GET pages combined", "url", client.Url+path, "payload", client.redactPayload(string(s)))
 	}
+
+	return gjson.ParseBytes(s), nil
 }
 
 // get is like Get but without pagination.
-func (client *Client) get(path string, mods ...func(*Req)) (Res, error) {
+func (client *Client) get(ctx context.Context, path string, mods ...func(*Req)) (Res, error) {
 	req := client.NewReq("GET", path, nil, mods...)
-	err := client.Authenticate()
+	err := client.AuthenticateCtx(ctx)
 	if err != nil {
 		return Res{}, err
 	}
 
-	return client.Do(req)
+	return client.DoCtx(ctx, req)
 }
 
-func pathWithOffset(path string, offset int) string {
-	if offset <= 1 {
-		return path
+// pathWithParam sets the query parameter key of the path to value, replacing an existing value.
+func pathWithParam(path, key string, value int) string {
+	param := fmt.Sprintf("%s=%d", key, value)
+	base, query, found := strings.Cut(path, "?")
+	if !found {
+		return base + "?" + param
 	}
 
-	sep := "?"
-	if strings.Contains(path, sep) {
-		sep = "&"
+	params := strings.Split(query, "&")
+	for i, p := range params {
+		if k, _, _ := strings.Cut(p, "="); k == key {
+			params[i] = param
+			return base + "?" + strings.Join(params, "&")
+		}
 	}
-
-	return fmt.Sprintf("%s%soffset=%d", path, sep, offset)
+	return path + "&" + param
 }
 
 type gatherer struct {
@@ -516,105 +701,164 @@ func (g *gatherer) GatherJSON(items []gjson.Result, separator byte) {
 
 // Delete makes a DELETE request.
 func (client *Client) Delete(path string, mods ...func(*Req)) (Res, error) {
+	return client.DeleteCtx(context.Background(), path, mods...)
+}
+
+// DeleteCtx is like Delete, but with a context.
+func (client *Client) DeleteCtx(ctx context.Context, path string, mods ...func(*Req)) (Res, error) {
 	req := client.NewReq("DELETE", path, nil, mods...)
-	err := client.Authenticate()
+	err := client.AuthenticateCtx(ctx)
 	if err != nil {
 		return Res{}, err
 	}
-	return client.Do(req)
+	return client.DoCtx(ctx, req)
 }
 
 // Post makes a POST request and returns a GJSON result.
 // Hint: Use the Body struct to easily create POST body data.
 func (client *Client) Post(path, data string, mods ...func(*Req)) (Res, error) {
+	return client.PostCtx(context.Background(), path, data, mods...)
+}
+
+// PostCtx is like Post, but with a context.
+func (client *Client) PostCtx(ctx context.Context, path, data string, mods ...func(*Req)) (Res, error) {
 	req := client.NewReq("POST", path, strings.NewReader(data), mods...)
-	err := client.Authenticate()
+	err := client.AuthenticateCtx(ctx)
 	if err != nil {
 		return Res{}, err
 	}
-	return client.Do(req)
+	return client.DoCtx(ctx, req)
 }
 
 // Put makes a PUT request and returns a GJSON result.
 // Hint: Use the Body struct to easily create PUT body data.
 func (client *Client) Put(path, data string, mods ...func(*Req)) (Res, error) {
+	return client.PutCtx(context.Background(), path, data, mods...)
+}
+
+// PutCtx is like Put, but with a context.
+func (client *Client) PutCtx(ctx context.Context, path, data string, mods ...func(*Req)) (Res, error) {
 	req := client.NewReq("PUT", path, strings.NewReader(data), mods...)
-	err := client.Authenticate()
+	err := client.AuthenticateCtx(ctx)
 	if err != nil {
 		return Res{}, err
 	}
-	return client.Do(req)
+	return client.DoCtx(ctx, req)
 }
 
 // Login authenticates to the Catalyst Center device.
 func (client *Client) Login() error {
+	return client.LoginCtx(context.Background())
+}
+
+// LoginCtx is like Login, but with a context.
+func (client *Client) LoginCtx(ctx context.Context) error {
+	if client.ctx.Err() != nil {
+		return ErrClientClosed
+	}
+	ctx, cancel := client.bind(ctx)
+	defer cancel()
+	ctx, span := client.telemetry().tracer.Start(ctx, "cc.login", SpanKindInternal)
+	err := closedErr(ctx, client.login(ctx))
+	span.End(err)
+	return err
+}
+
+// login implements LoginCtx.
+func (client *Client) login(ctx context.Context) error {
 	req := client.NewReq("POST", "/dna/system/api/v1/auth/token", strings.NewReader(""), NoLogPayload)
+	req.HttpReq = req.HttpReq.WithContext(ctx)
 	req.HttpReq.SetBasicAuth(client.Usr, client.Pwd)
+	if err := client.rateLimit(ctx, req.HttpReq.URL.Path); err != nil {
+		return err
+	}
+	issuedAt := time.Now()
 	httpRes, err := client.HttpClient.Do(req.HttpReq)
 	if err != nil {
-		return err
+		return &AuthError{Err: err}
 	}
 	if httpRes.StatusCode != 200 {
-		log.Printf("[ERROR] Authentication failed: StatusCode %v", httpRes.StatusCode)
-		return errors.New("authentication failed")
+		client.logger().ErrorContext(ctx, "Authentication failed", "status", httpRes.StatusCode)
+		return &AuthError{StatusCode: httpRes.StatusCode}
 	}
 	defer httpRes.Body.Close()
 	body, _ := io.ReadAll(httpRes.Body)
 	token := gjson.GetBytes(body, "Token").String()
-	if string(token) == "" {
-		log.Print("[ERROR] Token retrieval failed: no token in payload")
-		return errors.New("authentication failed")
+	if token == "" {
+		client.logger().ErrorContext(ctx, "Token retrieval failed: no token in payload", "status", httpRes.StatusCode)
+		return &AuthError{StatusCode: httpRes.StatusCode, Reason: "no token in payload"}
 	}
-	client.Token = string(token)
-	log.Printf("[DEBUG] Authentication successful")
+	client.setToken(token, issuedAt)
+	client.logger().DebugContext(ctx, "Authentication successful", "expires_at", client.TokenExpiresAt())
 	return nil
 }
 
-// Login if no token available.
+// Authenticate logs in if no token is available or the token is about to expire.
 func (client *Client) Authenticate() error {
+	return client.AuthenticateCtx(context.Background())
+}
+
+// AuthenticateCtx is like Authenticate, but with a context.
+func (client *Client) AuthenticateCtx(ctx context.Context) error {
 	client.AuthenticationMutex.Lock()
 	defer client.AuthenticationMutex.Unlock()
+	ctx, cancel := client.bind(ctx)
+	defer cancel()
 
-	if client.Token != "" {
+	token := client.Token()
+	if token != "" && !client.tokenExpiring() {
 		return nil
 	}
+	if token != "" {
+		client.logger().DebugContext(ctx, "Refreshing token before expiry", "expires_at", client.TokenExpiresAt())
+		client.telemetry().metrics.ReAuthenticated(ctx, "expiring")
+	}
 
 	for attempts := 0; attempts <= MaxAttempts; attempts++ {
-		err := client.Login()
+		err := client.LoginCtx(ctx)
 		if err == nil {
 			return nil
 		}
+		if ctx.Err() != nil {
+			return closedErr(ctx, ctx.Err())
+		}
+		if errors.Is(err, ErrClientClosed) {
+			return err
+		}
 
-		log.Printf("[ERROR] Authenticate: Login attempt %d failed: %v", attempts+1, err)
-		if ok := client.Backoff(attempts); !ok {
-			log.Printf("[ERROR] Authenticate: Max retries (%d) exhausted for login. Returning last error.", client.MaxRetries)
+		client.logger().ErrorContext(ctx, "Authenticate: Login attempt failed", "attempt", attempts, "error", err)
+		if ok := client.BackoffCtx(ctx, attempts); !ok {
+			if ctx.Err() != nil {
+				return closedErr(ctx, ctx.Err())
+			}
+			client.logger().ErrorContext(ctx, "Authenticate: Max retries exhausted for login", "max_retries", client.MaxRetries)
 			return fmt.Errorf("failed to authenticate after %d attempts: %w", attempts+1, err)
 		}
-		log.Printf("[WARNING] Authenticate: Retrying login after failure (attempt %d of %d)...", attempts+1, client.MaxRetries)
+		client.logger().WarnContext(ctx, "Authenticate: Retrying login after failure", "attempt", attempts, "max_retries", client.MaxRetries)
 	}
 	return fmt.Errorf("failed to authenticate after %d attempts", client.MaxRetries+1)
 }
 
 // Backoff waits following an exponential backoff algorithm
 func (client *Client) Backoff(attempts int) bool {
-	log.Printf("[DEBUG] Begining backoff method: attempts %v on %v", attempts, client.MaxRetries)
+	return client.BackoffCtx(context.Background(), attempts)
+}
+
+// BackoffCtx is like Backoff, but returns false early when the context is done.
+func (client *Client) BackoffCtx(ctx context.Context, attempts int) bool {
+	logger := client.logger().With("attempt", attempts, "max_retries", client.MaxRetries)
+	logger.DebugContext(ctx, "Beginning backoff method")
 	if attempts >= client.MaxRetries {
-		log.Printf("[DEBUG] Exit from backoff method with return value false")
+		logger.DebugContext(ctx, "Exit from backoff method with return value false")
 		return false
 	}
 
-	minDelay := time.Duration(client.BackoffMinDelay) * time.Second
-	maxDelay := time.Duration(client.BackoffMaxDelay) * time.Second
-
-	min := float64(minDelay)
-	backoff := min * math.Pow(client.BackoffDelayFactor, float64(attempts))
-	if backoff > float64(maxDelay) {
-		backoff = float64(maxDelay)
+	backoffDuration := backoffDelay(attempts, client.BackoffMinDelay, client.BackoffMaxDelay, client.BackoffDelayFactor)
+	logger.Log(ctx, LevelTrace, "Starting sleeping", "delay", backoffDuration.Round(time.Second))
+	if err := sleep(ctx, backoffDuration); err != nil {
+		logger.DebugContext(ctx, "Exit from backoff method with return value false", "error", err)
+		return false
 	}
-	backoff = (rand.Float64()/2+0.5)*(backoff-min) + min
-	backoffDuration := time.Duration(backoff)
-	log.Printf("[TRACE] Starting sleeping for %v", backoffDuration.Round(time.Second))
-	time.Sleep(backoffDuration)
-	log.Printf("[DEBUG] Exit from backoff method with return value true")
+	logger.DebugContext(ctx, "Exit from backoff method with return value true")
 	return true
 }
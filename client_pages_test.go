@@ -1,9 +1,11 @@
 package cc
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/h2non/gock.v1"
@@ -235,9 +237,11 @@ func TestClientGet_Concurrent(t *testing.T) {
 	// Since we are changing a package-level var, this test cannot be run on t.Parallel().
 	maxItems = 3
 
-	// GET that glues 3 pages.
+	// GET that glues 3 pages. The first page is requested again, if the optimistic request
+	// of the first page overlapped with any writers.
 	go func() {
 		gock.New(testURL).Get("/url").
+			Times(2).
 			Reply(200).
 			BodyString(`{"response":[{},{},{}]}`).
 			Map(func(resp *http.Response) *http.Response {
@@ -286,3 +290,351 @@ func TestClientGet_Concurrent(t *testing.T) {
 
 	assert.Contains(t, got.String(), "get,get,get")
 }
+
+// TestClientGetPages tests the Client.GetPages iterator.
+func TestClientGetPages(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	// For pagination tests to be readable, we use dummy page size of 3 instead of 500.
+	// Since we are changing a package-level var, this test cannot be run on t.Parallel().
+	maxItems = 3
+
+	gock.New(testURL).Get("/url").
+		Reply(200).
+		BodyString(`{"response":["1","2","3"]}`)
+	gock.New(testURL).Get("/url").MatchParam("offset", "4").
+		Reply(200).
+		BodyString(`{"response":["4","5"]}`)
+
+	var got []string
+	for page, err := range client.GetPages("/url") {
+		assert.NoError(t, err)
+		got = append(got, page.Get("response").Raw)
+	}
+	assert.Equal(t, []string{`["1","2","3"]`, `["4","5"]`}, got)
+
+	// Stop early, the second page is never requested.
+	gock.New(testURL).Get("/url").
+		Reply(200).
+		BodyString(`{"response":["1","2","3"]}`)
+	gock.New(testURL).Get("/url").MatchParam("offset", "4").
+		Reply(500)
+
+	for page, err := range client.GetPages("/url") {
+		assert.NoError(t, err)
+		assert.Equal(t, `["1","2","3"]`, page.Get("response").Raw)
+		break
+	}
+	assert.False(t, gock.IsDone())
+
+	// Error on a later page.
+	gock.Flush()
+	gock.New(testURL).Get("/url").
+		Reply(200).
+		BodyString(`{"response":["1","2","3"]}`)
+	gock.New(testURL).Get("/url").MatchParam("offset", "4").
+		Reply(404)
+
+	var errs int
+	for _, err := range client.GetPages("/url") {
+		if err != nil {
+			errs++
+		}
+	}
+	assert.Equal(t, 1, errs)
+}
+
+// TestClientGet_PageSize tests the PageSize request modifier.
+func TestClientGet_PageSize(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	gock.New(testURL).Get("/url").MatchParam("limit", "2").
+		Reply(200).
+		BodyString(`{"response":[1,2]}`)
+	gock.New(testURL).Get("/url").MatchParam("limit", "2").MatchParam("offset", "3").
+		Reply(200).
+		BodyString(`{"response":[3]}`)
+
+	res, err := client.Get("/url", PageSize(2))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"response":[1,2,3]}`, res.Raw)
+	assert.True(t, gock.IsDone())
+}
+
+// TestClientGet_PageSizeCapped tests pagination of an endpoint which ignores the requested page size.
+func TestClientGet_PageSizeCapped(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	gock.New(testURL).Get("/url").MatchParam("limit", "5").MatchParam("offset", "5").
+		Reply(200).
+		BodyString(`{"response":[5]}`)
+	gock.New(testURL).Get("/url").MatchParam("limit", "5").MatchParam("offset", "3").
+		Reply(200).
+		BodyString(`{"response":[3,4]}`)
+	gock.New(testURL).Get("/url").MatchParam("limit", "5").
+		Reply(200).
+		BodyString(`{"response":[1,2]}`)
+
+	res, err := client.Get("/url", PageSize(5))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"response":[1,2,3,4,5]}`, res.Raw)
+	assert.True(t, gock.IsDone())
+
+	// A single short page is followed by an empty one.
+	gock.New(testURL).Get("/url").MatchParam("offset", "3").
+		Reply(200).
+		BodyString(`{"response":[]}`)
+	gock.New(testURL).Get("/url").
+		Reply(200).
+		BodyString(`{"response":[1,2]}`)
+
+	res, err = client.Get("/url", PageSize(5))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"response":[1,2]}`, res.Raw)
+	assert.True(t, gock.IsDone())
+
+	// The probe repeats the first page, since the offset is ignored.
+	gock.New(testURL).Get("/url").
+		Times(2).
+		Reply(200).
+		BodyString(`{"response":[1,2,3]}`)
+
+	res, err = client.Get("/url", PageSize(100))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"response":[1,2,3]}`, res.Raw)
+	assert.True(t, gock.IsDone())
+
+	// A limit in the path is not probed beyond.
+	gock.New(testURL).Get("/url").
+		Reply(200).
+		BodyString(`{"response":[1,2]}`)
+
+	res, err = client.Get("/url?limit=5")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"response":[1,2]}`, res.Raw)
+	assert.True(t, gock.IsDone())
+}
+
+// TestClientGet_RegisteredPageSize tests pagination with a page size of the registry.
+func TestClientGet_RegisteredPageSize(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+	entries := pageSizes.entries
+	t.Cleanup(func() { pageSizes.entries = entries })
+	RegisterPageSize("/test/v2/*", 2)
+
+	gock.New(testURL).Get("/test/v2/items").MatchParam("limit", "2").MatchParam("offset", "3").
+		Reply(200).
+		BodyString(`{"response":[3]}`)
+	gock.New(testURL).Get("/test/v2/items").MatchParam("limit", "2").
+		Reply(200).
+		BodyString(`{"response":[1,2]}`)
+
+	res, err := client.Get("/test/v2/items")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"response":[1,2,3]}`, res.Raw)
+	assert.True(t, gock.IsDone())
+}
+
+// TestClientGet_PagesPageStyle tests pagination with page and pageSize query parameters.
+func TestClientGet_PagesPageStyle(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	gock.New(testURL).Get("/url").MatchParam("pageSize", "2").
+		Reply(200).
+		BodyString(`{"response":[1,2]}`)
+	gock.New(testURL).Get("/url").MatchParam("pageSize", "2").MatchParam("page", "2").
+		Reply(200).
+		BodyString(`{"response":[3,4]}`)
+	gock.New(testURL).Get("/url").MatchParam("pageSize", "2").MatchParam("page", "3").
+		Reply(200).
+		BodyString(`{"response":[]}`)
+
+	res, err := client.Get("/url?pageSize=2")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"response":[1,2,3,4]}`, res.Raw)
+	assert.True(t, gock.IsDone())
+}
+
+// TestClientGet_PagesTotalCount tests pagination of an endpoint with a lower page size than requested,
+// which is detected with the totalCount attribute.
+func TestClientGet_PagesTotalCount(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	gock.New(testURL).Get("/url").
+		Reply(200).
+		BodyString(`{"response":[1,2],"totalCount":5}`)
+	gock.New(testURL).Get("/url").MatchParam("offset", "3").
+		Reply(200).
+		BodyString(`{"response":[3,4],"totalCount":5}`)
+	gock.New(testURL).Get("/url").MatchParam("offset", "5").
+		Reply(200).
+		BodyString(`{"response":[5],"totalCount":5}`)
+
+	res, err := client.Get("/url")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"response":[1,2,3,4,5],"totalCount":5}`, res.Raw)
+	assert.True(t, gock.IsDone())
+}
+
+// TestPathWithParam tests the pathWithParam function.
+func TestPathWithParam(t *testing.T) {
+	assert.Equal(t, "/url?offset=4", pathWithParam("/url", "offset", 4))
+	assert.Equal(t, "/url?a=b&offset=4", pathWithParam("/url?a=b", "offset", 4))
+	assert.Equal(t, "/url?offset=7&a=b", pathWithParam("/url?offset=4&a=b", "offset", 7))
+}
+
+// TestClientGet_Optimistic tests that a single page is requested without waiting for writers.
+func TestClientGet_Optimistic(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	leave, err := client.enter(context.Background(), "/url", gateWriter)
+	assert.NoError(t, err)
+	defer leave()
+
+	gock.New(testURL).Get("/url").
+		Reply(200).
+		BodyString(`{"response":["1"]}`)
+
+	res, err := client.Get("/url")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"response":["1"]}`, res.Raw)
+}
+
+// TestClientGet_VerifyPages tests that the pagination is restarted when items shift between pages.
+func TestClientGet_VerifyPages(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	// For pagination tests to be readable, we use dummy page size of 3 instead of 500.
+	// Since we are changing a package-level var, this test cannot be run on t.Parallel().
+	maxItems = 3
+
+	// An item was inserted at the beginning by someone else, so item 3 shifted to the second page.
+	gock.New(testURL).Get("/url").MatchParam("offset", "4").
+		Reply(200).
+		BodyString(`{"response":[{"id":3},{"id":4}]}`)
+	gock.New(testURL).Get("/url").MatchParam("offset", "4").
+		Reply(200).
+		BodyString(`{"response":[{"id":4}]}`)
+	gock.New(testURL).Get("/url").
+		Times(2).
+		Reply(200).
+		BodyString(`{"response":[{"id":1},{"id":2},{"id":3}]}`)
+
+	res, err := client.Get("/url", VerifyPages("id"))
+	assert.NoError(t, err)
+	assert.Equal(t, `[1,2,3,4]`, res.Get("response.#.id").Raw)
+	assert.True(t, gock.IsDone())
+
+	// Total count does not match.
+	gock.New(testURL).Get("/url").MatchParam("offset", "4").
+		Persist().
+		Reply(200).
+		BodyString(`{"response":[{"id":4}],"totalCount":4}`)
+	gock.New(testURL).Get("/url").
+		Persist().
+		Reply(200).
+		BodyString(`{"response":[{"id":1},{"id":2},{"id":3}],"totalCount":5}`)
+
+	_, err = client.Get("/url", VerifyPages("id"))
+	assert.ErrorIs(t, err, ErrInconsistentPages)
+}
+
+// TestClientGet_ParallelPages tests fetching pages concurrently.
+func TestClientGet_ParallelPages(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	// For pagination tests to be readable, we use dummy page size of 3 instead of 500.
+	// Since we are changing a package-level var, this test cannot be run on t.Parallel().
+	maxItems = 3
+
+	gock.New(testURL).Get("/url/count").
+		Reply(200).
+		BodyString(`{"response":8}`)
+	gock.New(testURL).Get("/url").MatchParam("offset", "4").
+		Reply(200).
+		BodyString(`{"response":[4,5,6]}`)
+	gock.New(testURL).Get("/url").MatchParam("offset", "7").
+		Reply(200).
+		BodyString(`{"response":[7,8]}`)
+	gock.New(testURL).Get("/url").
+		Reply(200).
+		BodyString(`{"response":[1,2,3]}`)
+
+	res, err := client.Get("/url", ParallelPages(4), CountPath("/url/count"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"response":[1,2,3,4,5,6,7,8]}`, res.Raw)
+	assert.True(t, gock.IsDone())
+
+	// Total count in the page metadata
+	gock.New(testURL).Get("/url").MatchParam("offset", "4").
+		Reply(200).
+		BodyString(`{"response":[4,5,6],"totalCount":7}`)
+	gock.New(testURL).Get("/url").MatchParam("offset", "7").
+		Reply(200).
+		BodyString(`{"response":[7],"totalCount":7}`)
+	gock.New(testURL).Get("/url").
+		Reply(200).
+		BodyString(`{"response":[1,2,3],"totalCount":7}`)
+
+	var got []string
+	for page, err := range client.GetPages("/url", ParallelPages(2)) {
+		assert.NoError(t, err)
+		got = append(got, page.Get("response").Raw)
+	}
+	assert.Equal(t, []string{`[1,2,3]`, `[4,5,6]`, `[7]`}, got)
+	assert.True(t, gock.IsDone())
+}
+
+// TestClientGetPages_ParallelCancel tests that canceling the context or closing the client ends the iteration
+// over concurrently fetched pages.
+func TestClientGetPages_ParallelCancel(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+	closedClient := authenticatedTestClient()
+	maxItems = 3
+
+	iterate := func(ctx context.Context, client *Client, cancel func()) (int, error) {
+		done := make(chan struct{})
+		var pages int
+		var last error
+		go func() {
+			defer close(done)
+			for _, err := range client.GetPagesCtx(ctx, "/url", ParallelPages(4)) {
+				if err != nil {
+					last = err
+					continue
+				}
+				pages++
+				cancel()
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("iteration did not end")
+		}
+		return pages, last
+	}
+
+	gock.New(testURL).Get("/url").Times(2).Reply(200).BodyString(`{"response":[1,2,3],"totalCount":30}`)
+	gock.New(testURL).Get("/url").Persist().Reply(200).BodyString(`{"response":[4,5,6],"totalCount":30}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pages, err := iterate(ctx, client, cancel)
+	assert.Equal(t, 1, pages)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	pages, err = iterate(context.Background(), closedClient, func() { closedClient.Close() })
+	assert.Equal(t, 1, pages)
+	assert.ErrorIs(t, err, ErrClientClosed)
+}
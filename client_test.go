@@ -1,6 +1,7 @@
 package cc
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -22,15 +23,15 @@ var testURLs = []string{
 	testURLWithTrailingSlash,
 }
 
-func testClient() Client {
+func testClient() *Client {
 	client, _ := NewClient(testURL, "usr", "pwd", MaxRetries(0))
 	gock.InterceptClient(client.HttpClient)
 	return client
 }
 
-func authenticatedTestClient() Client {
+func authenticatedTestClient() *Client {
 	client := testClient()
-	client.Token = "ABC"
+	client.SetToken("ABC")
 	return client
 }
 
@@ -70,7 +71,16 @@ func TestClientLogin(t *testing.T) {
 
 	// Invalid HTTP status code
 	gock.New(testURL).Post("/dna/system/api/v1/auth/token").Reply(405)
-	assert.Error(t, client.Login())
+	var authErr *AuthError
+	assert.ErrorAs(t, client.Login(), &authErr)
+	assert.Equal(t, 405, authErr.StatusCode)
+
+	// Unanswered request
+	gock.New(testURL).Post("/dna/system/api/v1/auth/token").ReplyError(errors.New("connection refused"))
+	err := client.Login()
+	assert.ErrorAs(t, err, &authErr)
+	assert.Equal(t, 0, authErr.StatusCode)
+	assert.ErrorContains(t, err, "connection refused")
 }
 
 // TestClientGet tests the Client.Get method.
@@ -222,3 +232,118 @@ func TestClientWaitTask(t *testing.T) {
 	_, err = client.WaitTask(&Req{}, &Res{Raw: `{"executionId": "123"}`})
 	assert.Error(t, err)
 }
+
+// TestClientGetCtx tests that the context bounds the retries of the Client.GetCtx method.
+func TestClientGetCtx(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+	client.MaxRetries = 3
+	client.BackoffMinDelay = 60
+
+	gock.New(testURL).Get("/url").Times(4).Reply(503)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.GetCtx(ctx, "/url")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// TestClientWaitTaskCtx tests that the context bounds the polling of the Client.WaitTaskCtx method.
+func TestClientWaitTaskCtx(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	gock.New(testURL).Get("/api/v1/task/123").Persist().Reply(200).BodyString(`{"response": {"isError": false}}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	_, err := client.WaitTaskCtx(ctx, &Req{MaxAsyncWaitTime: 60}, &Res{Raw: `{"response": {"taskId": "123"}}`})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestClientErrorTypes tests that errors returned by the client can be inspected with errors.As.
+func TestClientErrorTypes(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	// HTTP error
+	gock.New(testURL).Get("/url").Reply(404).BodyString(`{"message":"not found"}`)
+	_, err := client.Get("/url")
+	var httpErr *HTTPError
+	if assert.ErrorAs(t, err, &httpErr) {
+		assert.Equal(t, 404, httpErr.StatusCode)
+		assert.Equal(t, "GET", httpErr.Method)
+		assert.Equal(t, testURL+"/url", httpErr.URL)
+		assert.Equal(t, "not found", httpErr.Body.Get("message").String())
+	}
+
+	// Task error
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"endTime": "1", "isError": true, "progress": "p", "failureReason": "r"}}`)
+	_, err = client.WaitTask(&Req{}, &Res{Raw: `{"response": {"taskId": "123"}}`})
+	var taskErr *TaskError
+	if assert.ErrorAs(t, err, &taskErr) {
+		assert.Equal(t, "123", taskErr.TaskID)
+		assert.Equal(t, "p", taskErr.Progress)
+		assert.Equal(t, "r", taskErr.FailureReason)
+	}
+
+	// Timeout error
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"isError": false}}`)
+	_, err = client.WaitTask(&Req{}, &Res{Raw: `{"response": {"taskId": "123"}}`})
+	var timeoutErr *TimeoutError
+	if assert.ErrorAs(t, err, &timeoutErr) {
+		assert.Equal(t, "123", timeoutErr.TaskID)
+	}
+}
+
+// TestClientClose tests the Client.Close method.
+func TestClientClose(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	// In-flight request
+	gock.New(testURL).Post("/url").Reply(200).BodyString(`{"response": {"taskId": "123"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Persist().Reply(200).BodyString(`{"response": {"isError": false}}`)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		client.Close()
+	}()
+	_, err := client.Post("/url", "{}", MaxAsyncWaitTime(60))
+	assert.ErrorIs(t, err, ErrClientClosed)
+
+	// Subsequent requests
+	_, err = client.Get("/url")
+	assert.ErrorIs(t, err, ErrClientClosed)
+	_, err = client.Delete("/url")
+	assert.ErrorIs(t, err, ErrClientClosed)
+	assert.NoError(t, client.Close())
+}
+
+// TestClientCloseDuringLogin tests that closing the client ends a login without waiting for a backoff.
+func TestClientCloseDuringLogin(t *testing.T) {
+	defer gock.Off()
+	client := testClient()
+	client.MaxRetries = 3
+	client.BackoffMinDelay = 60
+
+	gock.New(testURL).Post("/dna/system/api/v1/auth/token").Persist().Reply(200).Delay(time.Minute).BodyString(`{"Token": "ABC"}`)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		client.Close()
+	}()
+	start := time.Now()
+	err := client.Authenticate()
+	assert.ErrorIs(t, err, ErrClientClosed)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// TestClientWithContext tests that a client bound to a context is closed with the context.
+func TestClientWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client, _ := NewClient(testURL, "usr", "pwd", WithContext(ctx))
+	cancel()
+	_, err := client.Get("/url")
+	assert.ErrorIs(t, err, ErrClientClosed)
+}
@@ -0,0 +1,97 @@
+package cc
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrClientClosed is returned by requests of a client which was closed, see Client.Close.
+var ErrClientClosed = errors.New("client closed")
+
+// ErrInconsistentPages is returned by Get if items shifted between pages during pagination, see VerifyPages.
+var ErrInconsistentPages = errors.New("inconsistent pages")
+
+// HTTPError is returned when Catalyst Center responds with an unexpected HTTP status code.
+// Use errors.As to inspect it, e.g.
+//
+//	var httpErr *cc.HTTPError
+//	if errors.As(err, &httpErr) && httpErr.StatusCode == 404 { ... }
+type HTTPError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Method is the HTTP method of the request.
+	Method string
+	// URL is the full URL of the request.
+	URL string
+	// Body is the parsed response body.
+	Body Res
+	// ReAuthenticated indicates that the request failed even after re-authentication.
+	ReAuthenticated bool
+}
+
+func (e *HTTPError) Error() string {
+	if e.ReAuthenticated {
+		return fmt.Sprintf("HTTP Request failed: StatusCode %v (after re-authentication)", e.StatusCode)
+	}
+	return fmt.Sprintf("HTTP Request failed: StatusCode %v", e.StatusCode)
+}
+
+// TaskError is returned when an asynchronous task or execution finishes with a failure.
+type TaskError struct {
+	// TaskID is the ID of the task or execution.
+	TaskID string
+	// Progress is the last progress message of the task, if any.
+	Progress string
+	// FailureReason is the failure reason reported by Catalyst Center.
+	FailureReason string
+	// Res is the last task status response.
+	Res Res
+	// Failed are the failed tasks of a task tree, see WaitTaskTree.
+	Failed []TaskStatus
+}
+
+func (e *TaskError) Error() string {
+	if e.Progress != "" {
+		return fmt.Sprintf("task '%s' failed: %s, %s", e.TaskID, e.Progress, e.FailureReason)
+	}
+	return fmt.Sprintf("task '%s' failed: %s", e.TaskID, e.FailureReason)
+}
+
+// AuthError is returned when the login to Catalyst Center fails.
+type AuthError struct {
+	// StatusCode is the HTTP status code of the token response, zero if the request was not answered.
+	StatusCode int
+	// Reason describes why the authentication failed.
+	Reason string
+	// Err is the error of the token request if it was not answered, e.g. a connection error.
+	Err error
+}
+
+func (e *AuthError) Error() string {
+	if e.Err != nil {
+		return "authentication failed: " + e.Err.Error()
+	}
+	if e.Reason != "" {
+		return "authentication failed: " + e.Reason
+	}
+	return fmt.Sprintf("authentication failed: StatusCode %v", e.StatusCode)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError is returned when an asynchronous task does not complete within the maximum wait time.
+type TimeoutError struct {
+	// TaskID is the ID of the task or execution.
+	TaskID string
+	// Timeout is the maximum wait time that was exceeded.
+	Timeout time.Duration
+	// Res is the last task status response.
+	Res Res
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("maximum waiting time for task '%s' reached", e.TaskID)
+}
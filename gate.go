@@ -0,0 +1,231 @@
+package cc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// gateClass is the class of a gate holder. Holders of the same class may hold the gate concurrently,
+// holders of different classes exclude each other.
+type gateClass int
+
+const (
+	gateReader gateClass = iota
+	gateWriter
+)
+
+// String returns "reader" or "writer".
+func (c gateClass) String() string {
+	if c == gateWriter {
+		return "writer"
+	}
+	return "reader"
+}
+
+// gate is a fair, writer-preferring lock between readers (paginated GETs) and writers (DELETE/POST/PUT).
+// Holders of the same class join an active phase only while nobody of the other class is waiting.
+// When a phase ends, the gate switches to the other class if any of it is waiting and admits all its waiters
+// at once, so a stream of readers cannot starve writers and vice versa, and queued readers run concurrently.
+type gate struct {
+	mu sync.Mutex
+	// class is the class of the active or the last phase.
+	class   gateClass
+	active  int
+	waiting [2]int
+	// tickets numbers the waiters of each class, the ones below admitted were admitted by a phase switch.
+	tickets  [2]uint64
+	admitted [2]uint64
+	// writes is the number of writers admitted so far.
+	writes uint64
+	// wake is closed and replaced whenever waiters have to re-check the gate.
+	wake chan struct{}
+}
+
+func newGate() *gate {
+	return &gate{wake: make(chan struct{})}
+}
+
+// admissible reports whether a new holder of the class can enter without waiting, g.mu must be held.
+func (g *gate) admissible(class gateClass) bool {
+	return g.waiting[1-class] == 0 && (g.active == 0 || g.class == class)
+}
+
+// lock waits until the gate admits the class, the context is done or the client is closed.
+func (g *gate) lock(ctx context.Context, closed <-chan struct{}, class gateClass) error {
+	g.mu.Lock()
+	if g.admissible(class) {
+		g.enter(class, 1)
+		g.mu.Unlock()
+		return nil
+	}
+	ticket := g.tickets[class]
+	g.tickets[class]++
+	g.waiting[class]++
+	for ticket >= g.admitted[class] {
+		wake := g.wake
+		g.mu.Unlock()
+
+		var err error
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-closed:
+			err = ErrClientClosed
+		}
+		g.mu.Lock()
+		if err != nil {
+			if ticket < g.admitted[class] {
+				// Admitted in the meantime, so leave again.
+				g.active--
+			} else {
+				g.waiting[class]--
+			}
+			g.settle()
+			g.mu.Unlock()
+			return err
+		}
+	}
+	g.mu.Unlock()
+	return nil
+}
+
+// enter admits n holders of the class, g.mu must be held.
+func (g *gate) enter(class gateClass, n int) {
+	g.class = class
+	g.active += n
+	if class == gateWriter {
+		g.writes += uint64(n)
+	}
+}
+
+// settle admits waiters: once the gate is idle, all waiters of the other class, or else of the same class, are
+// admitted at once. While a phase is active, its waiting class joins it if nobody of the other class is waiting
+// any more. g.mu must be held.
+func (g *gate) settle() {
+	class := g.class
+	if g.active == 0 && g.waiting[1-class] > 0 {
+		class = 1 - class
+	}
+	if g.waiting[class] == 0 || (g.active > 0 && g.waiting[1-class] > 0) {
+		return
+	}
+	g.enter(class, g.waiting[class])
+	g.waiting[class] = 0
+	g.admitted[class] = g.tickets[class]
+	g.broadcast()
+}
+
+// generation returns the number of writers admitted so far and whether a writer is active.
+// A read is unaffected by writers if no writer was active when it started and the generation did not change.
+func (g *gate) generation() (uint64, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.writes, g.active > 0 && g.class == gateWriter
+}
+
+// unlock releases the gate.
+func (g *gate) unlock() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.active--
+	g.settle()
+}
+
+// broadcast wakes all waiters, g.mu must be held.
+func (g *gate) broadcast() {
+	close(g.wake)
+	g.wake = make(chan struct{})
+}
+
+// GateStats are the cumulative statistics of waiting for the reader/writer gate.
+type GateStats struct {
+	// ReaderWaits is the number of paginated GETs.
+	ReaderWaits int64
+	// ReaderWaitTime is the total time paginated GETs waited for writers.
+	ReaderWaitTime time.Duration
+	// WriterWaits is the number of DELETE/POST/PUT requests.
+	WriterWaits int64
+	// WriterWaitTime is the total time DELETE/POST/PUT requests waited for readers.
+	WriterWaitTime time.Duration
+}
+
+// gateStats are the atomic counters behind GateStats.
+type gateStats struct {
+	waits    [2]atomic.Int64
+	waitTime [2]atomic.Int64
+}
+
+// GateStats returns the statistics of waiting for the reader/writer gate.
+func (client *Client) GateStats() GateStats {
+	return GateStats{
+		ReaderWaits:    client.gateStats.waits[gateReader].Load(),
+		ReaderWaitTime: time.Duration(client.gateStats.waitTime[gateReader].Load()),
+		WriterWaits:    client.gateStats.waits[gateWriter].Load(),
+		WriterWaitTime: time.Duration(client.gateStats.waitTime[gateWriter].Load()),
+	}
+}
+
+// WithLockScope sets the function which maps a request path to the scope of the reader/writer gate.
+// Requests in different scopes do not wait for each other. By default, there is a single scope for the whole client.
+//
+//	client, _ := NewClient("https://cc1.cisco.com", "user", "password", WithLockScope(PathPrefixScope(5)))
+func WithLockScope(scope func(path string) string) func(*Client) {
+	return func(client *Client) {
+		client.LockScope = scope
+	}
+}
+
+// PathPrefixScope returns a lock scope function which uses the first n segments of the path as scope, e.g. with n = 5
+// "/dna/intent/api/v1/network-device/123" and "/dna/intent/api/v1/network-device?offset=501" share the scope
+// "/dna/intent/api/v1/network-device", while "/dna/intent/api/v1/template-programmer/template" is in another scope.
+func PathPrefixScope(n int) func(path string) string {
+	return func(path string) string {
+		path, _, _ = strings.Cut(path, "?")
+		segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", n+1)
+		if len(segments) > n {
+			segments = segments[:n]
+		}
+		return "/" + strings.Join(segments, "/")
+	}
+}
+
+// gate returns the gate of the path and its scope.
+func (client *Client) gate(path string) (*gate, string) {
+	scope := ""
+	if client.LockScope != nil {
+		scope = client.LockScope(path)
+	}
+	client.gatesMutex.Lock()
+	defer client.gatesMutex.Unlock()
+	g, ok := client.gates[scope]
+	if !ok {
+		g = newGate()
+		client.gates[scope] = g
+	}
+	return g, scope
+}
+
+// enter waits until the gate of the path admits a reader or a writer and returns the func to leave the gate.
+func (client *Client) enter(ctx context.Context, path string, class gateClass) (func(), error) {
+	if client.ctx.Err() != nil {
+		return nil, ErrClientClosed
+	}
+
+	g, scope := client.gate(path)
+	start := time.Now()
+	if err := g.lock(ctx, client.ctx.Done(), class); err != nil {
+		return nil, err
+	}
+	wait := time.Since(start)
+	client.gateStats.waits[class].Add(1)
+	client.gateStats.waitTime[class].Add(int64(wait))
+	client.telemetry().metrics.GateWaited(ctx, scope, class.String(), wait)
+	if wait > time.Millisecond {
+		client.logger().DebugContext(ctx, "Waited for reader/writer gate", "scope", scope, "writer", class == gateWriter, "duration", wait)
+	}
+	return g.unlock, nil
+}
@@ -0,0 +1,135 @@
+package cc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGateWriterPreferred tests that waiting writers are admitted before new readers.
+func TestGateWriterPreferred(t *testing.T) {
+	g := newGate()
+	ctx := context.Background()
+	assert.NoError(t, g.lock(ctx, nil, gateReader))
+
+	order := make(chan gateClass, 2)
+	go func() {
+		_ = g.lock(ctx, nil, gateWriter)
+		order <- gateWriter
+		g.unlock()
+	}()
+	assert.Eventually(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.waiting[gateWriter] == 1
+	}, time.Second, time.Millisecond)
+
+	go func() {
+		_ = g.lock(ctx, nil, gateReader)
+		order <- gateReader
+		g.unlock()
+	}()
+	assert.Eventually(t, func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.waiting[gateReader] == 1
+	}, time.Second, time.Millisecond)
+
+	g.unlock()
+	assert.Equal(t, gateWriter, <-order)
+	assert.Equal(t, gateReader, <-order)
+}
+
+// TestGateAdmitAll tests that all waiters of a class are admitted together at a phase switch, and that waiting
+// readers join the active phase once no writer is waiting any more.
+func TestGateAdmitAll(t *testing.T) {
+	g := newGate()
+	ctx := context.Background()
+	assert.NoError(t, g.lock(ctx, nil, gateReader))
+	waiting := func(class gateClass, n int) {
+		assert.Eventually(t, func() bool {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			return g.waiting[class] == n
+		}, time.Second, time.Millisecond)
+	}
+
+	go func() { _ = g.lock(ctx, nil, gateWriter) }()
+	waiting(gateWriter, 1)
+	admitted := make(chan struct{}, 3)
+	for range 3 {
+		go func() {
+			_ = g.lock(ctx, nil, gateReader)
+			admitted <- struct{}{}
+		}()
+	}
+	waiting(gateReader, 3)
+
+	g.unlock()
+	waiting(gateWriter, 0)
+	g.unlock()
+	for range 3 {
+		<-admitted
+	}
+	assert.Equal(t, 3, g.active)
+
+	// A writer giving up lets the queued readers join the active phase.
+	cctx, cancel := context.WithCancel(ctx)
+	go func() { _ = g.lock(cctx, nil, gateWriter) }()
+	waiting(gateWriter, 1)
+	go func() {
+		_ = g.lock(ctx, nil, gateReader)
+		admitted <- struct{}{}
+	}()
+	waiting(gateReader, 1)
+	cancel()
+	<-admitted
+	assert.Equal(t, 4, g.active)
+}
+
+// TestGateCancel tests that waiting for the gate can be canceled.
+func TestGateCancel(t *testing.T) {
+	g := newGate()
+	assert.NoError(t, g.lock(context.Background(), nil, gateWriter))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, g.lock(ctx, nil, gateReader), context.DeadlineExceeded)
+	assert.Equal(t, 0, g.waiting[gateReader])
+
+	// Writers share the gate.
+	assert.NoError(t, g.lock(context.Background(), nil, gateWriter))
+}
+
+// TestClientLockScope tests that requests in different lock scopes do not wait for each other.
+func TestClientLockScope(t *testing.T) {
+	client, _ := NewClient(testURL, "usr", "pwd", WithLockScope(PathPrefixScope(5)))
+	ctx := context.Background()
+
+	leave, err := client.enter(ctx, "/dna/intent/api/v1/template-programmer/template", gateWriter)
+	assert.NoError(t, err)
+	defer leave()
+
+	leaveReader, err := client.enter(ctx, "/dna/intent/api/v1/network-device?offset=501", gateReader)
+	assert.NoError(t, err)
+	leaveReader()
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = client.enter(ctx, "/dna/intent/api/v1/template-programmer/project", gateReader)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	stats := client.GateStats()
+	assert.Equal(t, int64(1), stats.WriterWaits)
+	assert.Equal(t, int64(1), stats.ReaderWaits)
+}
+
+// TestPathPrefixScope tests the PathPrefixScope function.
+func TestPathPrefixScope(t *testing.T) {
+	scope := PathPrefixScope(5)
+	assert.Equal(t, "/dna/intent/api/v1/network-device", scope("/dna/intent/api/v1/network-device/123/config"))
+	assert.Equal(t, "/dna/intent/api/v1/network-device", scope("/dna/intent/api/v1/network-device?offset=1"))
+	assert.Equal(t, "/dna/intent/api/v1", scope("/dna/intent/api/v1"))
+}
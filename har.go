@@ -0,0 +1,171 @@
+package cc
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"time"
+)
+
+// WithHAR writes all HTTP exchanges of the client, including logins, retried attempts and task polls, to a
+// HAR 1.2 file, which can be opened in browser developer tools or attached to support cases. The file is valid
+// after every exchange. Secrets are redacted as in logs: the X-Auth-Token, Authorization (basic auth) and cookie
+// headers, the token of login responses and the RedactedPaths of payloads. Recording never fails a request, errors
+// writing the file are logged. The file is closed by Close.
+//
+//	client, _ := NewClient("https://cc1.cisco.com", "user", "password", WithHAR("session.har"))
+func WithHAR(path string) func(*Client) {
+	return func(client *Client) {
+		creator, _ := json.Marshal(harCreator{Name: "go-catalystcenter", Version: moduleVersion()})
+		file := &jsonArrayFile{
+			path:   path,
+			prefix: "{\n  \"log\": {\n    \"version\": \"1.2\",\n    \"creator\": " + string(creator) + ",\n    \"entries\": [",
+			suffix: "    ]\n  }\n}",
+		}
+		client.HttpClient.Transport = newRecorder(client, file, func(e exchange) any { return newHAREntry(e) })
+	}
+}
+
+// harFile is the root object of a HAR 1.2 file.
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []harNameVal `json:"cookies"`
+	Headers     []harNameVal `json:"headers"`
+	QueryString []harNameVal `json:"queryString"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []harNameVal `json:"cookies"`
+	Headers     []harNameVal `json:"headers"`
+	Content     harContent   `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harNameVal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// newHAREntry converts an exchange to a HAR entry.
+func newHAREntry(e exchange) harEntry {
+	entry := harEntry{
+		StartedDateTime: e.start.Format(time.RFC3339Nano),
+		Time:            milliseconds(e.wait + e.receive),
+		Request: harRequest{
+			Method:      e.req.Method,
+			URL:         e.req.URL.String(),
+			HTTPVersion: e.req.Proto,
+			Cookies:     []harNameVal{},
+			Headers:     harHeaders(e.req.Header),
+			QueryString: []harNameVal{},
+			HeadersSize: -1,
+			BodySize:    len(e.reqBody),
+		},
+		Response: harResponse{
+			Status:      e.res.StatusCode,
+			StatusText:  http.StatusText(e.res.StatusCode),
+			HTTPVersion: e.res.Proto,
+			Cookies:     []harNameVal{},
+			Headers:     harHeaders(e.res.Header),
+			Content: harContent{
+				Size:     len(e.resBody),
+				MimeType: e.res.Header.Get("Content-Type"),
+				Text:     e.resBody,
+			},
+			HeadersSize: -1,
+			BodySize:    len(e.resBody),
+		},
+		Timings: harTimings{Wait: milliseconds(e.wait), Receive: milliseconds(e.receive)},
+	}
+	for name, values := range e.req.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameVal{name, value})
+		}
+	}
+	sort.Slice(entry.Request.QueryString, func(i, j int) bool {
+		return entry.Request.QueryString[i].Name < entry.Request.QueryString[j].Name
+	})
+	if e.reqBody != "" {
+		entry.Request.PostData = &harPostData{MimeType: e.req.Header.Get("Content-Type"), Text: e.reqBody}
+	}
+	return entry
+}
+
+// harHeaders returns the redacted headers sorted by name.
+func harHeaders(header http.Header) []harNameVal {
+	headers := []harNameVal{}
+	for name, value := range redactHeaders(header) {
+		headers = append(headers, harNameVal{name, value})
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+	return headers
+}
+
+// milliseconds converts a duration to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// moduleVersion returns the version of this module in the running binary, or "(devel)" if unknown.
+func moduleVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/netascode/go-catalystcenter" {
+				return dep.Version
+			}
+		}
+	}
+	return "(devel)"
+}
@@ -0,0 +1,82 @@
+package cc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// TestWithHAR tests writing the HTTP exchanges of a client to a HAR file.
+func TestWithHAR(t *testing.T) {
+	defer gock.Off()
+	path := filepath.Join(t.TempDir(), "session.har")
+	client := testClient()
+	client.MaxRetries = 1
+	client.BackoffMinDelay = 0
+	WithHAR(path)(client)
+
+	gock.New(testURL).Post("/dna/system/api/v1/auth/token").Reply(200).BodyString(`{"Token": "secret"}`)
+	gock.New(testURL).Post("/url").Reply(503)
+	gock.New(testURL).Post("/url").Reply(202).BodyString(`{"response": {"taskId": "123"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"progress": "running"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"progress": "done", "endTime": 1700000001000}}`)
+
+	assert.NoError(t, client.Login())
+	_, err := client.Post("/url", `{"name": "a"}`)
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	var har harFile
+	assert.NoError(t, json.Unmarshal(data, &har))
+	assert.Equal(t, "1.2", har.Log.Version)
+	if !assert.Len(t, har.Log.Entries, 5) {
+		return
+	}
+	header := func(headers []harNameVal, name string) string {
+		for _, h := range headers {
+			if h.Name == name {
+				return h.Value
+			}
+		}
+		return ""
+	}
+	login := har.Log.Entries[0]
+	assert.Equal(t, "POST", login.Request.Method)
+	assert.Equal(t, testURL+"/dna/system/api/v1/auth/token", login.Request.URL)
+	assert.Equal(t, "REDACTED", header(login.Request.Headers, "Authorization"))
+	assert.JSONEq(t, `{"Token": "REDACTED"}`, login.Response.Content.Text)
+	assert.Equal(t, 503, har.Log.Entries[1].Response.Status)
+	assert.Equal(t, 202, har.Log.Entries[2].Response.Status)
+	assert.Equal(t, "REDACTED", header(har.Log.Entries[2].Request.Headers, "X-Auth-Token"))
+	assert.JSONEq(t, `{"name": "a"}`, har.Log.Entries[2].Request.PostData.Text)
+	assert.Equal(t, testURL+"/api/v1/task/123", har.Log.Entries[4].Request.URL)
+
+	// The file is closed with the client.
+	file := client.HttpClient.Transport.(*recorder).file
+	client.Close()
+	assert.Eventually(t, func() bool { return file.append(struct{}{}) != nil }, time.Second, time.Millisecond)
+}
+
+// TestWithHARErrors tests that a HAR file which cannot be written neither fails nor repeats requests.
+func TestWithHARErrors(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+	client.MaxRetries = 3
+	WithHAR(filepath.Join(t.TempDir(), "missing", "session.har"))(client)
+
+	gock.New(testURL).Post("/url").Times(1).Reply(200).BodyString(`{"response": "ok"}`)
+	gock.New(testURL).Post("/url").Reply(500)
+
+	res, err := client.Post("/url", `{}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Get("response").String())
+	assert.Len(t, gock.Pending(), 1)
+}
@@ -0,0 +1,142 @@
+package cc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// LevelTrace is the slog level used for the most verbose messages, e.g. backoff sleeps.
+const LevelTrace = slog.LevelDebug - 4
+
+// redacted replaces secret values in log messages.
+const redacted = "REDACTED"
+
+// redactedHeaders are the HTTP headers which are never logged in clear text.
+var redactedHeaders = []string{"X-Auth-Token", "Authorization", "Cookie", "Set-Cookie"}
+
+// WithLogger sets the structured logger of the client. By default, messages are written to the standard log
+// package with a bracketed level prefix, e.g. "[DEBUG] HTTP Request method=GET url=...".
+func WithLogger(logger *slog.Logger) func(*Client) {
+	return func(client *Client) {
+		client.Logger = logger
+	}
+}
+
+// RedactPaths adds GJSON paths of request and response payload values which are replaced before logging, e.g.
+//
+//	client, _ := NewClient("https://cc1.cisco.com", "user", "password", RedactPaths("password", "response.#.snmpAuthPassphrase"))
+func RedactPaths(paths ...string) func(*Client) {
+	return func(client *Client) {
+		client.RedactedPaths = append(client.RedactedPaths, paths...)
+	}
+}
+
+// logger returns the configured logger or the default one writing to the standard log package.
+func (client *Client) logger() *slog.Logger {
+	if client.Logger != nil {
+		return client.Logger
+	}
+	return defaultLogger
+}
+
+// redactPayload returns the payload with all values at the RedactedPaths replaced.
+// Array queries like "response.#.password" are expanded to the individual items.
+func (client *Client) redactPayload(payload string) string {
+	for _, path := range client.RedactedPaths {
+		for _, p := range expandPath(payload, path) {
+			if !gjson.Get(payload, p).Exists() {
+				continue
+			}
+			if s, err := sjson.Set(payload, p, redacted); err == nil {
+				payload = s
+			}
+		}
+	}
+	return payload
+}
+
+// expandPath expands "#" path components to the indexes of the corresponding array.
+func expandPath(payload, path string) []string {
+	i := strings.Index(path, ".#.")
+	if i < 0 {
+		return []string{path}
+	}
+	prefix, suffix := path[:i], path[i+3:]
+	var paths []string
+	for n := range gjson.Get(payload, prefix).Array() {
+		paths = append(paths, expandPath(payload, fmt.Sprintf("%s.%d.%s", prefix, n, suffix))...)
+	}
+	return paths
+}
+
+// redactHeaders returns the headers for logging with all secrets replaced.
+func redactHeaders(header http.Header) map[string]string {
+	m := make(map[string]string, len(header))
+	for k, v := range header {
+		m[k] = strings.Join(v, ", ")
+	}
+	for _, k := range redactedHeaders {
+		if header.Get(k) != "" {
+			m[http.CanonicalHeaderKey(k)] = redacted
+		}
+	}
+	return m
+}
+
+var defaultLogger = slog.New(&legacyHandler{})
+
+// legacyHandler is a slog.Handler writing to the standard log package in the "[LEVEL] message" format
+// which is expected by existing consumers such as Terraform providers.
+type legacyHandler struct {
+	attrs  []slog.Attr
+	prefix string
+}
+
+func (h *legacyHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *legacyHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	switch {
+	case r.Level < slog.LevelDebug:
+		b.WriteString("[TRACE] ")
+	case r.Level < slog.LevelInfo:
+		b.WriteString("[DEBUG] ")
+	case r.Level < slog.LevelWarn:
+		b.WriteString("[INFO] ")
+	case r.Level < slog.LevelError:
+		b.WriteString("[WARNING] ")
+	default:
+		b.WriteString("[ERROR] ")
+	}
+	b.WriteString(r.Message)
+	for _, a := range h.attrs {
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		fmt.Fprintf(&b, " %s%s=%v", h.prefix, a.Key, a.Value)
+		return true
+	})
+	log.Print(b.String())
+	return nil
+}
+
+func (h *legacyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	all := append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		all = append(all, slog.Attr{Key: h.prefix + a.Key, Value: a.Value})
+	}
+	return &legacyHandler{attrs: all, prefix: h.prefix}
+}
+
+func (h *legacyHandler) WithGroup(name string) slog.Handler {
+	return &legacyHandler{attrs: h.attrs, prefix: h.prefix + name + "."}
+}
@@ -0,0 +1,48 @@
+package cc
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// TestClientLogger tests structured logging with redaction.
+func TestClientLogger(t *testing.T) {
+	defer gock.Off()
+	var buf bytes.Buffer
+	client := authenticatedTestClient()
+	WithLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))(client)
+	RedactPaths("password", "response.#.secret")(client)
+
+	gock.New(testURL).Post("/url").Reply(200).BodyString(`{"response":[{"name":"a","secret":"s1"},{"name":"b","secret":"s2"}]}`)
+	_, err := client.Post("/url", `{"name":"x","password":"pwd123"}`)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, `"msg":"HTTP Request"`)
+	assert.Contains(t, out, `"method":"POST"`)
+	assert.Contains(t, out, `"status":200`)
+	assert.Contains(t, out, `"X-Auth-Token":"REDACTED"`)
+	assert.NotContains(t, out, "ABC")
+	assert.NotContains(t, out, "pwd123")
+	assert.NotContains(t, out, "s1")
+	assert.NotContains(t, out, "s2")
+}
+
+// TestLegacyHandler tests the default logger writing to the standard log package.
+func TestLegacyHandler(t *testing.T) {
+	var buf bytes.Buffer
+	out := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(out)
+	flags := log.Flags()
+	log.SetFlags(0)
+	defer log.SetFlags(flags)
+
+	defaultLogger.With("method", "GET").WithGroup("g").Debug("HTTP Request", "attempt", 1)
+	assert.Equal(t, "[DEBUG] HTTP Request method=GET g.attempt=1\n", buf.String())
+}
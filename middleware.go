@@ -0,0 +1,47 @@
+package cc
+
+import "context"
+
+// Response is the outcome of a request passed through the middleware chain.
+type Response struct {
+	// StatusCode is the status code of the last HTTP response, zero if no response was received.
+	// For asynchronous operations, it is the status code of the request starting the task.
+	StatusCode int
+	// Res is the parsed response, or the task status response of an asynchronous operation.
+	Res Res
+}
+
+// Handler handles a request made with Do. The request may be modified before it is sent, e.g. to add headers.
+type Handler func(ctx context.Context, req *Req) (Response, error)
+
+// Use adds middleware wrapping every request made with Do, including the requests of Get, Post, Put and Delete
+// and every page of a paginated GET. The first middleware added is the outermost one. The innermost handler
+// authenticates, retries, logs and waits for asynchronous tasks; logins do not pass through the chain.
+// Middleware may return without calling next, e.g. to serve a cached response.
+// Use must not be called concurrently with requests, e.g.
+//
+//	client.Use(func(next cc.Handler) cc.Handler {
+//		return func(ctx context.Context, req *cc.Req) (cc.Response, error) {
+//			req.HttpReq.Header.Set("__persistbapioutput", "true")
+//			res, err := next(ctx, req)
+//			log.Printf("%s %s: %d", req.HttpReq.Method, req.HttpReq.URL.Path, res.StatusCode)
+//			return res, err
+//		}
+//	})
+func (client *Client) Use(middleware ...func(next Handler) Handler) {
+	client.middleware = append(client.middleware, middleware...)
+}
+
+// handler returns the middleware chain around the request handling of the client.
+func (client *Client) handler() Handler {
+	h := Handler(func(ctx context.Context, req *Req) (Response, error) {
+		var response Response
+		var err error
+		response.Res, err = client.do(ctx, *req, &response.StatusCode)
+		return response, err
+	})
+	for i := len(client.middleware) - 1; i >= 0; i-- {
+		h = client.middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,76 @@
+package cc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// TestClientUse tests the middleware chain of the Client.
+func TestClientUse(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	var calls []string
+	var responses []Response
+	var errs []error
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Req) (Response, error) {
+			calls = append(calls, "outer")
+			res, err := next(ctx, req)
+			responses = append(responses, res)
+			errs = append(errs, err)
+			return res, err
+		}
+	}, func(next Handler) Handler {
+		return func(ctx context.Context, req *Req) (Response, error) {
+			calls = append(calls, "inner")
+			req.HttpReq.Header.Set("__persistbapioutput", "true")
+			return next(ctx, req)
+		}
+	})
+
+	gock.New(testURL).Get("/url").MatchHeader("__persistbapioutput", "true").Reply(200).BodyString(`{"a": 1}`)
+	res, err := client.Get("/url")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), res.Get("a").Int())
+
+	gock.New(testURL).Post("/url").Reply(400).BodyString(`{"error": "bad"}`)
+	_, err = client.Post("/url", "{}")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"outer", "inner", "outer", "inner"}, calls)
+	if assert.Len(t, responses, 2) {
+		assert.Equal(t, 200, responses[0].StatusCode)
+		assert.NoError(t, errs[0])
+		assert.Equal(t, 400, responses[1].StatusCode)
+		assert.Equal(t, "bad", responses[1].Res.Get("error").String())
+		var httpErr *HTTPError
+		assert.ErrorAs(t, errs[1], &httpErr)
+	}
+	assert.True(t, gock.IsDone())
+}
+
+// TestClientUseShortCircuit tests middleware returning without calling the next handler.
+func TestClientUseShortCircuit(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+	errChaos := errors.New("chaos")
+	client.Use(func(next Handler) Handler {
+		return func(ctx context.Context, req *Req) (Response, error) {
+			if req.HttpReq.Method == "DELETE" {
+				return Response{}, errChaos
+			}
+			return Response{StatusCode: 200, Res: Res{Raw: `{"cached": true}`}}, nil
+		}
+	})
+
+	res, err := client.Get("/url")
+	assert.NoError(t, err)
+	assert.True(t, res.Get("cached").Bool())
+	_, err = client.Delete("/url")
+	assert.ErrorIs(t, err, errChaos)
+}
@@ -0,0 +1,142 @@
+// Package otelcc instruments a Catalyst Center client with OpenTelemetry tracing and metrics, e.g.
+//
+//	client, _ := cc.NewClient("https://cc1.cisco.com", "user", "password",
+//		otelcc.WithTracerProvider(otel.GetTracerProvider()),
+//		otelcc.WithMeterProvider(otel.GetMeterProvider()))
+//
+// It is a separate module with its own go.mod, so that modules using only the client do not require
+// OpenTelemetry.
+package otelcc
+
+import (
+	"context"
+	"time"
+
+	cc "github.com/netascode/go-catalystcenter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the OpenTelemetry instrumentation scope of the client.
+const instrumentationName = "github.com/netascode/go-catalystcenter"
+
+// WithTracerProvider traces the client operations with spans of the provider, see cc.Tracer.
+func WithTracerProvider(provider trace.TracerProvider) func(*cc.Client) {
+	return cc.WithTracer(NewTracer(provider))
+}
+
+// WithMeterProvider records the request duration by method and path template, retries,
+// throttled (429) responses and task durations with instruments of the provider.
+func WithMeterProvider(provider metric.MeterProvider) func(*cc.Client) {
+	return cc.WithMetricsSink(NewMetricsSink(provider))
+}
+
+// NewTracer returns a cc.Tracer creating spans with the provider.
+func NewTracer(provider trace.TracerProvider) cc.Tracer {
+	return &tracer{tracer: provider.Tracer(instrumentationName)}
+}
+
+type tracer struct {
+	tracer trace.Tracer
+}
+
+// Start implements cc.Tracer.
+func (t *tracer) Start(ctx context.Context, name string, kind cc.SpanKind, attrs ...cc.Attr) (context.Context, cc.Span) {
+	spanKind := trace.SpanKindInternal
+	if kind == cc.SpanKindClient {
+		spanKind = trace.SpanKindClient
+	}
+	ctx, s := t.tracer.Start(ctx, name, trace.WithSpanKind(spanKind), trace.WithAttributes(attributes(attrs)...))
+	return ctx, &span{span: s}
+}
+
+type span struct {
+	span trace.Span
+}
+
+// SetAttributes implements cc.Span. A status code of 400 or above marks the span as failed.
+func (s *span) SetAttributes(attrs ...cc.Attr) {
+	for _, attr := range attrs {
+		if code, ok := attr.Value.(int); ok && attr.Key == "http.response.status_code" && code >= 400 {
+			s.span.SetStatus(codes.Error, "")
+		}
+	}
+	s.span.SetAttributes(attributes(attrs)...)
+}
+
+// End implements cc.Span.
+func (s *span) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// attributes converts span attributes to OpenTelemetry attributes.
+func attributes(attrs []cc.Attr) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		switch v := attr.Value.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(attr.Key, v))
+		case int:
+			kvs = append(kvs, attribute.Int(attr.Key, v))
+		case int64:
+			kvs = append(kvs, attribute.Int64(attr.Key, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(attr.Key, v))
+		}
+	}
+	return kvs
+}
+
+// NewMetricsSink returns a cc.MetricsSink recording with instruments of the provider.
+func NewMetricsSink(provider metric.MeterProvider) cc.MetricsSink {
+	meter := provider.Meter(instrumentationName)
+	m := &metricsSink{}
+	// Instrument creation only fails for invalid names, the returned instruments are usable in any case.
+	m.requestDuration, _ = meter.Float64Histogram("cc.client.request.duration",
+		metric.WithDescription("Duration of requests including retries and task waits"), metric.WithUnit("s"))
+	m.retries, _ = meter.Int64Counter("cc.client.request.retries",
+		metric.WithDescription("Number of retried HTTP attempts"), metric.WithUnit("{retry}"))
+	m.throttled, _ = meter.Int64Counter("cc.client.request.throttled",
+		metric.WithDescription("Number of HTTP responses with status 429"), metric.WithUnit("{response}"))
+	m.taskDuration, _ = meter.Float64Histogram("cc.client.task.duration",
+		metric.WithDescription("Duration of waits for asynchronous tasks"), metric.WithUnit("s"))
+	return m
+}
+
+type metricsSink struct {
+	cc.NopMetricsSink
+	requestDuration metric.Float64Histogram
+	retries         metric.Int64Counter
+	throttled       metric.Int64Counter
+	taskDuration    metric.Float64Histogram
+}
+
+// RequestDone implements cc.MetricsSink.
+func (m *metricsSink) RequestDone(ctx context.Context, method, template string, duration time.Duration, errorType string) {
+	attrs := []attribute.KeyValue{attribute.String("http.request.method", method), attribute.String("url.template", template)}
+	if errorType != "" {
+		attrs = append(attrs, attribute.String("error.type", errorType))
+	}
+	m.requestDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(attrs...))
+}
+
+// Retried implements cc.MetricsSink.
+func (m *metricsSink) Retried(ctx context.Context, method, template string) {
+	m.retries.Add(ctx, 1, metric.WithAttributes(attribute.String("http.request.method", method), attribute.String("url.template", template)))
+}
+
+// Throttled implements cc.MetricsSink.
+func (m *metricsSink) Throttled(ctx context.Context, method, template string) {
+	m.throttled.Add(ctx, 1, metric.WithAttributes(attribute.String("http.request.method", method), attribute.String("url.template", template)))
+}
+
+// TaskDone implements cc.MetricsSink.
+func (m *metricsSink) TaskDone(ctx context.Context, kind cc.TaskKind, outcome string, duration time.Duration) {
+	m.taskDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(attribute.String("cc.task.kind", string(kind)), attribute.String("cc.task.outcome", outcome)))
+}
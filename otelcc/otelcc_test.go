@@ -0,0 +1,80 @@
+package otelcc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	cc "github.com/netascode/go-catalystcenter"
+	"github.com/netascode/go-catalystcenter/cctest"
+	"github.com/stretchr/testify/assert"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestClientOpenTelemetry tests the OpenTelemetry spans and metrics of a request.
+func TestClientOpenTelemetry(t *testing.T) {
+	server := cctest.NewServer()
+	defer server.Close()
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	client, _ := server.NewClient(
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		cc.WithRetryPolicy(cc.RetryPolicyFunc(func(req *cc.Req, attempt int, httpRes *http.Response, res cc.Res, err error) (bool, time.Duration) {
+			return attempt == 0, 0
+		})))
+	defer client.Close()
+
+	server.RespondTask("POST", "/url", "123")
+	server.AddTask("123", cctest.TaskState{Done: true})
+	assert.NoError(t, client.Login())
+	server.RateLimit(1, 0)
+
+	_, err := client.Post("/url", "{}")
+	assert.NoError(t, err)
+
+	var names []string
+	var root sdktrace.ReadOnlySpan
+	for _, span := range spans.Ended() {
+		names = append(names, span.Name())
+		if span.Name() == "POST /url" {
+			root = span
+		}
+	}
+	assert.ElementsMatch(t, []string{"cc.login", "POST", "cc.backoff", "POST", "cc.task.poll", "cc.task.wait", "POST /url"}, names)
+	if assert.NotNil(t, root) {
+		for _, span := range spans.Ended() {
+			switch span.Name() {
+			case "POST", "cc.backoff", "cc.task.wait":
+				assert.Equal(t, root.SpanContext().SpanID(), span.Parent().SpanID(), span.Name())
+			}
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	sums := map[string]int64{}
+	counts := map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					sums[m.Name] += dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					counts[m.Name] += dp.Count
+				}
+			}
+		}
+	}
+	assert.Equal(t, int64(1), sums["cc.client.request.retries"])
+	assert.Equal(t, int64(1), sums["cc.client.request.throttled"])
+	assert.Equal(t, uint64(1), counts["cc.client.request.duration"])
+	assert.Equal(t, uint64(1), counts["cc.client.task.duration"])
+}
@@ -0,0 +1,441 @@
+package cc
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+)
+
+// GetPages makes paginated GET requests and lazily yields every page as soon as it is received, instead of
+// combining all pages into a single result like Get does. The items of a page are in its "response" array, e.g.
+//
+//	for page, err := range client.GetPages("/dna/intent/api/v1/network-device") {
+//		if err != nil {
+//			return err
+//		}
+//		for _, device := range page.Get("response").Array() {
+//			println(device.Get("hostname").String())
+//		}
+//	}
+//
+// Pages are fetched concurrently with the ParallelPages modifier, but still yielded in order.
+// Like Get, writing requests of the client wait until the iteration is finished, so the loop body must not
+// issue DELETE, POST or PUT requests with the same client. Neither may it call Get, GetPages or another paginated
+// GET of the same lock scope: readers queue behind waiting writers, so it deadlocks as soon as any writing request
+// of another goroutine is waiting.
+func (client *Client) GetPages(path string, mods ...func(*Req)) iter.Seq2[Res, error] {
+	return client.GetPagesCtx(context.Background(), path, mods...)
+}
+
+// GetPagesCtx is like GetPages, but the context bounds all the page requests.
+func (client *Client) GetPagesCtx(ctx context.Context, path string, mods ...func(*Req)) iter.Seq2[Res, error] {
+	return func(yield func(Res, error) bool) {
+		leave, err := client.enter(ctx, path, gateReader)
+		if err != nil {
+			yield(Res{}, err)
+			return
+		}
+		defer leave()
+
+		for raw, err := range client.pageIter(ctx, path, mods, Res{}, client.NewReq("GET", path, nil, mods...)) {
+			if !yield(raw, err) {
+				return
+			}
+		}
+	}
+}
+
+// PaginationStyle is the way an endpoint paginates its results.
+type PaginationStyle int
+
+const (
+	// PaginationAuto detects the style from the query parameters of the path: "page" or "pageSize" select
+	// PaginationPage, otherwise PaginationOffset is used. A "limit" or "pageSize" parameter sets the page size.
+	PaginationAuto PaginationStyle = iota
+	// PaginationOffset uses the 1-based "offset" and the "limit" query parameters.
+	PaginationOffset
+	// PaginationPage uses the 1-based "page" and the "pageSize" query parameters.
+	PaginationPage
+	// PaginationNone disables pagination.
+	PaginationNone
+)
+
+// pageSizes is the registry of page sizes of endpoints, see RegisterPageSize.
+var pageSizes struct {
+	mu      sync.RWMutex
+	entries []pageSizeEntry
+}
+
+type pageSizeEntry struct {
+	pattern string
+	size    int
+}
+
+// RegisterPageSize sets the page size of paginated GETs of the endpoints matching the pattern, for endpoints which
+// return fewer items per page than the default of 500 and no "totalCount". The page size is sent as "limit" or
+// "pageSize" query parameter, unless the request sets one with PageSize or in its path. The pattern syntax is the
+// one of RegisterSyncEndpoint, e.g.
+//
+//	cc.RegisterPageSize("/dna/intent/api/v2/*", 100)
+//
+// The registry is shared by all clients, later registrations take precedence.
+func RegisterPageSize(pattern string, size int) {
+	pageSizes.mu.Lock()
+	defer pageSizes.mu.Unlock()
+	pageSizes.entries = append(pageSizes.entries, pageSizeEntry{pattern, size})
+}
+
+// registeredPageSize returns the registered page size of the endpoint of the uri.
+func registeredPageSize(uri string) (int, bool) {
+	path, _, _ := strings.Cut(uri, "?")
+	pageSizes.mu.RLock()
+	defer pageSizes.mu.RUnlock()
+	for i := len(pageSizes.entries) - 1; i >= 0; i-- {
+		if entry := pageSizes.entries[i]; entry.size > 0 && matchTemplate(entry.pattern, path) {
+			return entry.size, true
+		}
+	}
+	return 0, false
+}
+
+// pagination holds the pagination parameters of a single Get.
+type pagination struct {
+	style    PaginationStyle
+	pageSize int
+	// explicit indicates that the page size has to be sent as a query parameter.
+	explicit bool
+	// capped is the page size served by a server which returned fewer items than requested on the first page.
+	capped int
+	// probed holds the items of a short first page until the probe for a next page is checked by repeated.
+	probed string
+	// next is the offset or page number of the next page.
+	next int
+}
+
+// newPagination determines the pagination parameters from the path and the request modifiers.
+func newPagination(req Req, path string) pagination {
+	p := pagination{style: req.Pagination, pageSize: req.PageSize, explicit: req.PageSize > 0, next: 1}
+	_, query, _ := strings.Cut(path, "?")
+	params, _ := url.ParseQuery(query)
+	if p.style == PaginationAuto {
+		p.style = PaginationOffset
+		if params.Has("page") || params.Has("pageSize") {
+			p.style = PaginationPage
+		}
+	}
+
+	sizeKey, nextKey := "limit", "offset"
+	if p.style == PaginationPage {
+		sizeKey, nextKey = "pageSize", "page"
+	}
+	if n, err := strconv.Atoi(params.Get(sizeKey)); err == nil && n > 0 && !p.explicit {
+		p.pageSize = n
+	}
+	if n, err := strconv.Atoi(params.Get(nextKey)); err == nil && n > 0 {
+		p.next = n
+	}
+	if n, ok := registeredPageSize(path); ok && p.pageSize <= 0 {
+		p.pageSize, p.explicit = n, true
+	}
+	if p.pageSize <= 0 {
+		p.pageSize = maxItems
+	}
+	return p
+}
+
+// path returns the path of the next page.
+func (p pagination) path(path string, first bool) string {
+	sizeKey, nextKey := "limit", "offset"
+	if p.style == PaginationPage {
+		sizeKey, nextKey = "pageSize", "page"
+	}
+	if p.explicit {
+		path = pathWithParam(path, sizeKey, p.pageSize)
+	}
+	if !first {
+		path = pathWithParam(path, nextKey, p.next)
+	}
+	return path
+}
+
+// advance moves to the next page and reports whether there is one, given the items received so far.
+// A "totalCount" in the page metadata takes precedence over comparing the number of items with the page size.
+// If the page size was set with PageSize or RegisterPageSize and the first page is shorter, a single next page is
+// probed for, since the server may cap the page size below the requested one. If that page is neither empty nor
+// a repetition of the first page, see repeated, the size of the first page is expected from then on.
+// A "limit" or "pageSize" in the path is trusted and never probed beyond.
+func (p *pagination) advance(raw Res, items, received int) bool {
+	if p.style == PaginationNone || items == 0 {
+		return false
+	}
+	p.skip(items)
+
+	if total, ok := totalCount(raw); ok {
+		return received < total
+	}
+	if p.explicit && received == items && items < p.pageSize {
+		p.capped = items
+		p.probed = raw.Get("response").Raw
+		return true
+	}
+	if p.capped > 0 {
+		return items == p.capped
+	}
+	return items == p.pageSize
+}
+
+// repeated reports whether the page is the probe for a next page and repeats the items of the first page,
+// i.e. the server ignores the offset or page parameter. Such a page ends the pagination.
+func (p *pagination) repeated(raw Res) bool {
+	probed := p.probed
+	p.probed = ""
+	return probed != "" && raw.Get("response").Raw == probed
+}
+
+// skip moves to the next page, given the number of items per page.
+func (p *pagination) skip(items int) {
+	if p.style == PaginationPage {
+		p.next++
+	} else {
+		p.next += items
+	}
+}
+
+// totalCount returns the total number of items from the page metadata.
+func totalCount(raw Res) (int, bool) {
+	for _, path := range []string{"totalCount", "page.totalCount", "response.totalCount"} {
+		if total := raw.Get(path); total.Type == gjson.Number {
+			return int(total.Int()), true
+		}
+	}
+	return 0, false
+}
+
+// pageVerifier detects items which shifted between pages while paginating, by the key of the items.
+type pageVerifier struct {
+	key   string
+	seen  map[string]bool
+	total int
+	// hasTotal indicates that total is known from the page metadata.
+	hasTotal bool
+}
+
+func newPageVerifier(key string) *pageVerifier {
+	return &pageVerifier{key: key, seen: make(map[string]bool)}
+}
+
+// verify checks a page for items already received on a previous page and for a changed total count.
+func (v *pageVerifier) verify(raw Res, items []gjson.Result) error {
+	for _, item := range items {
+		key := item.Get(v.key)
+		if !key.Exists() {
+			continue
+		}
+		if v.seen[key.String()] {
+			return fmt.Errorf("%w: duplicate item %s=%s", ErrInconsistentPages, v.key, key.String())
+		}
+		v.seen[key.String()] = true
+	}
+
+	if total, ok := totalCount(raw); ok {
+		if v.hasTotal && total != v.total {
+			return fmt.Errorf("%w: total count changed from %d to %d", ErrInconsistentPages, v.total, total)
+		}
+		v.total, v.hasTotal = total, true
+	}
+	return nil
+}
+
+// done checks that the number of received items matches the total count.
+func (v *pageVerifier) done(received int) error {
+	if v.hasTotal && received != v.total {
+		return fmt.Errorf("%w: received %d items, but total count is %d", ErrInconsistentPages, received, v.total)
+	}
+	return nil
+}
+
+// getPage fetches a single page of a paginated GET.
+func (client *Client) getPage(ctx context.Context, path string, mods ...func(*Req)) (Res, error) {
+	res, err := client.get(ctx, path, mods...)
+	if err == nil {
+		page, _, _ := strings.Cut(path, "?")
+		client.telemetry().metrics.PageFetched(ctx, urlTemplate(page))
+	}
+	return res, err
+}
+
+// pages yields the pages of a paginated GET without any concurrency protection.
+// The iteration ends after the last page, after the first page which is not a "response" array or after an error.
+// If firstPage exists, it is used instead of requesting the first page.
+func (client *Client) pages(ctx context.Context, path string, mods []func(*Req), firstPage Res) iter.Seq2[Res, error] {
+	return func(yield func(Res, error) bool) {
+		p := newPagination(client.NewReq("GET", path, nil, mods...), path)
+		received := 0
+		for first := true; ; first = false {
+			raw, err := firstPage, error(nil)
+			if !first || !firstPage.Exists() {
+				raw, err = client.getPage(ctx, p.path(path, first), mods...)
+			}
+			if err != nil {
+				yield(raw, err)
+				return
+			}
+
+			response := raw.Get("response")
+			if !first && response.Exists() && !response.IsArray() {
+				yield(gjson.Parse("null"), fmt.Errorf("expected `response` to be an array, but got: %s", response.Type))
+				return
+			}
+			if !first && p.repeated(raw) {
+				return
+			}
+
+			if !yield(raw, nil) {
+				return
+			}
+
+			if !response.IsArray() {
+				return
+			}
+			items := len(response.Array())
+			received += items
+			if !p.advance(raw, items, received) {
+				return
+			}
+		}
+	}
+}
+
+// pageIter yields the pages of a paginated GET, concurrently fetched if ParallelPages is set.
+func (client *Client) pageIter(ctx context.Context, path string, mods []func(*Req), first Res, opts Req) iter.Seq2[Res, error] {
+	if opts.ParallelPages > 1 {
+		return client.parallelPages(ctx, path, mods, first, opts)
+	}
+	return client.pages(ctx, path, mods, first)
+}
+
+// parallelPages is like pages, but after the first page, the remaining pages are fetched by up to ParallelPages
+// concurrent workers. The pages are still yielded in order. If the total number of items is unknown, the remaining
+// pages are fetched sequentially.
+func (client *Client) parallelPages(ctx context.Context, path string, mods []func(*Req), first Res, opts Req) iter.Seq2[Res, error] {
+	return func(yield func(Res, error) bool) {
+		p := newPagination(opts, path)
+		first := first
+		if !first.Exists() {
+			var err error
+			first, err = client.getPage(ctx, p.path(path, true), mods...)
+			if err != nil {
+				yield(first, err)
+				return
+			}
+		}
+
+		response := first.Get("response")
+		items := len(response.Array())
+		if !response.IsArray() || !p.advance(first, items, items) {
+			yield(first, nil)
+			return
+		}
+
+		total, ok := client.totalItems(ctx, first, opts)
+		if !ok {
+			for raw, err := range client.pages(ctx, path, mods, first) {
+				if !yield(raw, err) {
+					return
+				}
+			}
+			return
+		}
+		if !yield(first, nil) {
+			return
+		}
+
+		// The first page reveals the actual page size of the endpoint.
+		remaining := (total - items + items - 1) / items
+		paths := make([]string, remaining)
+		for i := range paths {
+			paths[i] = p.path(path, false)
+			p.skip(items)
+		}
+
+		ctx, stop := client.bind(ctx)
+		defer stop()
+		ctx, cancel := context.WithCancel(ctx)
+		type result struct {
+			res Res
+			err error
+		}
+		results := make([]chan result, remaining)
+		for i := range results {
+			results[i] = make(chan result, 1)
+		}
+		next := make(chan int)
+		var wg sync.WaitGroup
+		for range min(opts.ParallelPages, remaining) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					res, err := client.getPage(ctx, paths[i], mods...)
+					results[i] <- result{res, err}
+				}
+			}()
+		}
+		go func() {
+			defer close(next)
+			for i := range paths {
+				select {
+				case next <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		defer func() {
+			cancel()
+			wg.Wait()
+		}()
+
+		for i := range results {
+			// Pages which were never requested after a cancellation would block forever.
+			var r result
+			select {
+			case r = <-results[i]:
+			case <-ctx.Done():
+			}
+			if err := ctx.Err(); err != nil {
+				yield(Res{}, closedErr(ctx, err))
+				return
+			}
+			if r.err != nil {
+				yield(r.res, r.err)
+				return
+			}
+			if response := r.res.Get("response"); response.Exists() && !response.IsArray() {
+				yield(gjson.Parse("null"), fmt.Errorf("expected `response` to be an array, but got: %s", response.Type))
+				return
+			}
+			if !yield(r.res, nil) {
+				return
+			}
+		}
+	}
+}
+
+// totalItems returns the total number of items, from the count API or from the page metadata.
+func (client *Client) totalItems(ctx context.Context, first Res, opts Req) (int, bool) {
+	if opts.CountPath != "" {
+		count, err := client.get(ctx, opts.CountPath)
+		if err == nil && count.Get("response").Type == gjson.Number {
+			return int(count.Get("response").Int()), true
+		}
+		client.logger().WarnContext(ctx, "Cannot get the total number of items", "url", client.Url+opts.CountPath, "error", err)
+	}
+	return totalCount(first)
+}
@@ -0,0 +1,114 @@
+package cc
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// PollPolicy defines how often the status of an asynchronous task or execution is polled and for how long.
+// Set it for the whole client with WithPollPolicy or for a single request with UsePollPolicy.
+//
+// The first poll is sent immediately, the following ones after InitialInterval, growing by Multiplier up to
+// MaxInterval. Polling stops when the task completes or the maximum duration is exceeded.
+type PollPolicy struct {
+	// InitialInterval is the delay before the second poll. If zero, the one of DefaultPollPolicy is used.
+	InitialInterval time.Duration
+	// MaxInterval is the maximum delay between two polls.
+	MaxInterval time.Duration
+	// Multiplier is the factor by which the delay grows after every poll, values below 1 are treated as 1.
+	Multiplier float64
+	// Jitter randomizes every delay by up to this fraction, e.g. 0.1 for +/- 10%.
+	Jitter float64
+	// MaxDuration is the maximum time to wait for the task. If zero, MaxAsyncWaitTime of the request is used.
+	// The MaxAsyncWaitTime modifier of a request overrides the MaxDuration of the client.
+	MaxDuration time.Duration
+}
+
+// DefaultPollPolicy is the PollPolicy used when none is configured.
+var DefaultPollPolicy = PollPolicy{
+	InitialInterval: 500 * time.Millisecond,
+	MaxInterval:     2 * time.Second,
+	Multiplier:      1.5,
+}
+
+// WithPollPolicy sets the PollPolicy used for all requests of the client.
+func WithPollPolicy(policy PollPolicy) func(*Client) {
+	return func(client *Client) {
+		client.PollPolicy = policy
+	}
+}
+
+// UsePollPolicy sets the PollPolicy of this request, overriding the one of the client.
+func UsePollPolicy(policy PollPolicy) func(*Req) {
+	return func(req *Req) {
+		req.PollPolicy = policy
+	}
+}
+
+// poller paces the polls of a single asynchronous task according to a PollPolicy.
+type poller struct {
+	policy   PollPolicy
+	start    time.Time
+	timeout  time.Duration
+	interval time.Duration
+	polls    int
+}
+
+// maxWait returns the maximum time to wait for an asynchronous operation of the request.
+func (req *Req) maxWait() time.Duration {
+	if req.PollPolicy.MaxDuration > 0 {
+		return req.PollPolicy.MaxDuration
+	}
+	return time.Duration(req.MaxAsyncWaitTime) * time.Second
+}
+
+// newPoller creates a poller for the request, starting now.
+func newPoller(req *Req) *poller {
+	timeout := req.maxWait()
+	policy := req.PollPolicy
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = DefaultPollPolicy.InitialInterval
+	}
+	return &poller{policy: policy, start: time.Now(), timeout: timeout}
+}
+
+// wait sleeps until the next poll is due. The first call returns immediately.
+// Delays are cut short at the deadline, so that the last poll happens right at it.
+func (p *poller) wait(ctx context.Context) error {
+	defer func() { p.polls++ }()
+	if p.polls == 0 {
+		return nil
+	}
+	if p.polls == 1 {
+		p.interval = p.policy.InitialInterval
+	} else {
+		p.interval = time.Duration(float64(p.interval) * max(p.policy.Multiplier, 1))
+	}
+	if p.policy.MaxInterval > 0 && p.interval > p.policy.MaxInterval {
+		p.interval = p.policy.MaxInterval
+	}
+	delay := p.interval
+	if p.policy.Jitter > 0 {
+		delay += time.Duration(p.policy.Jitter * (2*rand.Float64() - 1) * float64(delay))
+	}
+	if remaining := time.Until(p.deadline()); delay > remaining {
+		delay = max(remaining, 0)
+	}
+	return sleep(ctx, delay)
+}
+
+// deadline returns the time after which polling stops.
+func (p *poller) deadline() time.Time {
+	return p.start.Add(p.timeout)
+}
+
+// expired reports whether the deadline has passed.
+func (p *poller) expired() bool {
+	return !time.Now().Before(p.deadline())
+}
+
+// elapsed returns the time since the first poll.
+func (p *poller) elapsed() time.Duration {
+	return time.Since(p.start)
+}
@@ -0,0 +1,74 @@
+package cc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// TestPollerWait tests the intervals of the poller.
+func TestPollerWait(t *testing.T) {
+	req := &Req{PollPolicy: PollPolicy{InitialInterval: time.Millisecond, MaxInterval: 3 * time.Millisecond, Multiplier: 2, MaxDuration: time.Minute}}
+	p := newPoller(req)
+
+	var intervals []time.Duration
+	for range 4 {
+		assert.NoError(t, p.wait(context.Background()))
+		intervals = append(intervals, p.interval)
+	}
+	assert.Equal(t, []time.Duration{0, time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}, intervals)
+
+	// Delays are cut short at the deadline
+	req = &Req{PollPolicy: PollPolicy{InitialInterval: time.Hour, MaxDuration: 10 * time.Millisecond}}
+	p = newPoller(req)
+	start := time.Now()
+	assert.NoError(t, p.wait(context.Background()))
+	assert.NoError(t, p.wait(context.Background()))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.True(t, p.expired())
+}
+
+// TestClientWaitTaskPollPolicy tests that only the deadline of the PollPolicy terminates polling.
+func TestClientWaitTaskPollPolicy(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+	assert.Equal(t, DefaultPollPolicy, client.NewReq("GET", "/url", nil).PollPolicy)
+	WithPollPolicy(PollPolicy{InitialInterval: time.Second})(client)
+	assert.Equal(t, time.Second, client.NewReq("GET", "/url", nil).PollPolicy.InitialInterval)
+
+	var polls int
+	gock.New(testURL).Post("/url").Reply(202).BodyString(`{"response": {"taskId": "123"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Persist().Reply(200).BodyString(`{"response": {"progress": "running"}}`)
+
+	start := time.Now()
+	_, err := client.Post("/url", "{}", UsePollPolicy(PollPolicy{InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond, MaxDuration: 300 * time.Millisecond}),
+		OnTaskProgress(func(TaskStatus) { polls++ }))
+	var timeoutErr *TimeoutError
+	if assert.ErrorAs(t, err, &timeoutErr) {
+		assert.Equal(t, 300*time.Millisecond, timeoutErr.Timeout)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+	assert.Greater(t, polls, MaxAttempts)
+}
+
+// TestReqMaxWait tests that the MaxAsyncWaitTime of a request overrides the MaxDuration of the client and that
+// polling and synchronous execution share the deadline.
+func TestReqMaxWait(t *testing.T) {
+	client := testClient()
+	WithPollPolicy(PollPolicy{MaxDuration: 90 * time.Second})(client)
+
+	req := client.NewReq("POST", "/dna/intent/api/v1/site", nil)
+	assert.Equal(t, 90*time.Second, newPoller(&req).timeout)
+	assert.Equal(t, "90", req.HttpReq.Header.Get("__runsynctimeout"))
+
+	req = client.NewReq("POST", "/dna/intent/api/v1/site", nil, MaxAsyncWaitTime(10))
+	assert.Equal(t, 10*time.Second, newPoller(&req).timeout)
+	assert.Equal(t, "10", req.HttpReq.Header.Get("__runsynctimeout"))
+
+	req = client.NewReq("POST", "/dna/intent/api/v1/site", nil, MaxAsyncWaitTime(10), UsePollPolicy(PollPolicy{MaxDuration: 1500 * time.Millisecond}))
+	assert.Equal(t, 1500*time.Millisecond, newPoller(&req).timeout)
+	assert.Equal(t, "2", req.HttpReq.Header.Get("__runsynctimeout"))
+}
@@ -0,0 +1,142 @@
+// Package promcc exports measurements of Catalyst Center clients as Prometheus metrics, e.g.
+//
+//	collector := promcc.NewCollector()
+//	prometheus.MustRegister(collector)
+//	client, _ := cc.NewClient("https://cc1.cisco.com", "user", "password", cc.WithMetricsSink(collector))
+//
+// A collector can be shared by multiple clients. The package has its own go.mod, which keeps
+// client_golang and its dependencies out of the modules importing only the client.
+package promcc
+
+import (
+	"context"
+	"time"
+
+	cc "github.com/netascode/go-catalystcenter"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector and a cc.MetricsSink.
+type Collector struct {
+	cc.NopMetricsSink
+	inFlight        *prometheus.GaugeVec
+	requestDuration *prometheus.HistogramVec
+	retries         *prometheus.CounterVec
+	throttled       *prometheus.CounterVec
+	taskDuration    *prometheus.HistogramVec
+	gateWait        *prometheus.HistogramVec
+	pages           *prometheus.CounterVec
+	reauths         *prometheus.CounterVec
+	rateLimitDelay  *prometheus.HistogramVec
+}
+
+// NewCollector creates a Collector, which must be registered with a prometheus.Registerer.
+func NewCollector() *Collector {
+	return &Collector{
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cc_client_requests_in_flight",
+			Help: "Number of requests in progress, including retries and task waits.",
+		}, []string{"method", "template"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cc_client_request_duration_seconds",
+			Help:    "Duration of requests including retries and task waits.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 14),
+		}, []string{"method", "template", "error_type"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cc_client_request_retries_total",
+			Help: "Number of retried HTTP attempts.",
+		}, []string{"method", "template"}),
+		throttled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cc_client_throttled_responses_total",
+			Help: "Number of HTTP responses with status 429.",
+		}, []string{"method", "template"}),
+		taskDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cc_client_task_duration_seconds",
+			Help:    "Duration of waits for asynchronous tasks by outcome.",
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 12),
+		}, []string{"kind", "outcome"}),
+		gateWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cc_client_gate_wait_seconds",
+			Help:    "Time requests waited for the reader/writer gate.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		}, []string{"scope", "class"}),
+		pages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cc_client_pages_fetched_total",
+			Help: "Number of pages fetched by paginated GETs.",
+		}, []string{"template"}),
+		reauths: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cc_client_reauthentications_total",
+			Help: "Number of logins after a 401 response or before the token expired.",
+		}, []string{"reason"}),
+		rateLimitDelay: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cc_client_rate_limit_delay_seconds",
+			Help:    "Delays of requests by client-side rate limits.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+		}, []string{"template"}),
+	}
+}
+
+// collectors returns the metrics of the collector.
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{c.inFlight, c.requestDuration, c.retries, c.throttled, c.taskDuration, c.gateWait, c.pages, c.reauths, c.rateLimitDelay}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// RequestStarted implements cc.MetricsSink.
+func (c *Collector) RequestStarted(_ context.Context, method, template string) {
+	c.inFlight.WithLabelValues(method, template).Inc()
+}
+
+// RequestDone implements cc.MetricsSink.
+func (c *Collector) RequestDone(_ context.Context, method, template string, duration time.Duration, errorType string) {
+	c.inFlight.WithLabelValues(method, template).Dec()
+	c.requestDuration.WithLabelValues(method, template, errorType).Observe(duration.Seconds())
+}
+
+// Retried implements cc.MetricsSink.
+func (c *Collector) Retried(_ context.Context, method, template string) {
+	c.retries.WithLabelValues(method, template).Inc()
+}
+
+// Throttled implements cc.MetricsSink.
+func (c *Collector) Throttled(_ context.Context, method, template string) {
+	c.throttled.WithLabelValues(method, template).Inc()
+}
+
+// TaskDone implements cc.MetricsSink.
+func (c *Collector) TaskDone(_ context.Context, kind cc.TaskKind, outcome string, duration time.Duration) {
+	c.taskDuration.WithLabelValues(string(kind), outcome).Observe(duration.Seconds())
+}
+
+// GateWaited implements cc.MetricsSink.
+func (c *Collector) GateWaited(_ context.Context, scope, class string, wait time.Duration) {
+	c.gateWait.WithLabelValues(scope, class).Observe(wait.Seconds())
+}
+
+// PageFetched implements cc.MetricsSink.
+func (c *Collector) PageFetched(_ context.Context, template string) {
+	c.pages.WithLabelValues(template).Inc()
+}
+
+// ReAuthenticated implements cc.MetricsSink.
+func (c *Collector) ReAuthenticated(_ context.Context, reason string) {
+	c.reauths.WithLabelValues(reason).Inc()
+}
+
+// RateLimited implements cc.MetricsSink.
+func (c *Collector) RateLimited(_ context.Context, template string, delay time.Duration) {
+	c.rateLimitDelay.WithLabelValues(template).Observe(delay.Seconds())
+}
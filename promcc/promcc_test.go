@@ -0,0 +1,55 @@
+package promcc
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	cc "github.com/netascode/go-catalystcenter"
+	"github.com/netascode/go-catalystcenter/cctest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCollector tests the metrics reported by the Collector.
+func TestCollector(t *testing.T) {
+	server := cctest.NewServer()
+	defer server.Close()
+	collector := NewCollector()
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(t, registry.Register(collector))
+	client, _ := server.NewClient(cc.WithMetricsSink(collector),
+		cc.WithRateLimit("/dna/intent/api/v1/network-device", 1, 50*time.Millisecond),
+		cc.WithRetryPolicy(cc.RetryPolicyFunc(func(req *cc.Req, attempt int, httpRes *http.Response, res cc.Res, err error) (bool, time.Duration) {
+			return attempt == 0, 0
+		})))
+	defer client.Close()
+
+	server.PageSize = 2
+	server.AddCollection("/dna/intent/api/v1/network-device", 1, 2, 3)
+	_, err := client.Get("/dna/intent/api/v1/network-device", cc.PageSize(2))
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, testutil.ToFloat64(collector.pages.WithLabelValues("/dna/intent/api/v1/network-device")))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector.rateLimitDelay))
+
+	server.RespondTask("POST", "/dna/intent/api/v1/site", "123")
+	server.AddTask("123", cctest.TaskState{IsError: true, FailureReason: "bad"})
+	server.RateLimit(1, 0)
+	_, err = client.Post("/dna/intent/api/v1/site", "{}", cc.NoWait)
+	assert.NoError(t, err)
+	server.ExpireTokens()
+	_, err = client.WaitTaskID(t.Context(), cc.TaskKindTask, "123")
+	assert.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.reauths.WithLabelValues("unauthorized")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.retries.WithLabelValues("POST", "/dna/intent/api/v1/site")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.throttled.WithLabelValues("POST", "/dna/intent/api/v1/site")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(collector.inFlight.WithLabelValues("POST", "/dna/intent/api/v1/site")))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector.taskDuration))
+	assert.Equal(t, 2, testutil.CollectAndCount(collector.gateWait))
+
+	problems, err := testutil.GatherAndLint(registry)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+}
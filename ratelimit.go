@@ -0,0 +1,113 @@
+package cc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithRateLimit paces requests to paths matching the pattern to n requests per interval, using a token bucket
+// which allows bursts of up to n requests. In the pattern, "*" matches any sequence of characters and an empty
+// pattern matches all paths. The budget is shared by all goroutines using the client and every HTTP request counts,
+// including retries, logins and task polls. A limit with n or interval not greater than zero is ignored.
+// If multiple limits match a path, all of them apply, e.g.
+//
+//	client, _ := NewClient("https://cc1.cisco.com", "user", "password",
+//		WithRateLimit("", 500, time.Minute),
+//		WithRateLimit("/dna/intent/api/v1/network-device*", 100, time.Minute))
+func WithRateLimit(pattern string, n int, interval time.Duration) func(*Client) {
+	return func(client *Client) {
+		if n <= 0 || interval <= 0 {
+			return
+		}
+		client.rateLimiters = append(client.rateLimiters, &rateLimiter{
+			pattern:  pattern,
+			capacity: float64(n),
+			tokens:   float64(n),
+			rate:     float64(n) / interval.Seconds(),
+			last:     time.Now(),
+		})
+	}
+}
+
+// rateLimiter is a token bucket for the paths matching a pattern.
+type rateLimiter struct {
+	pattern  string
+	capacity float64
+	// rate is the number of tokens added per second.
+	rate float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns how long to wait until it is available.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.capacity, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token which was not used.
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.capacity, l.tokens+1)
+}
+
+// rateLimit waits until all rate limits matching the path allow another request.
+func (client *Client) rateLimit(ctx context.Context, path string) error {
+	var delay time.Duration
+	var reserved []*rateLimiter
+	now := time.Now()
+	for _, l := range client.rateLimiters {
+		if l.pattern != "" && !matchPattern(l.pattern, path) {
+			continue
+		}
+		delay = max(delay, l.reserve(now))
+		reserved = append(reserved, l)
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	client.logger().DebugContext(ctx, "Rate limit reached, delaying request", "path", path, "delay", delay)
+	client.telemetry().metrics.RateLimited(ctx, urlTemplate(path), delay)
+	if err := sleep(ctx, delay); err != nil {
+		for _, l := range reserved {
+			l.cancel()
+		}
+		return err
+	}
+	return nil
+}
+
+// matchPattern reports whether the path matches the pattern, in which "*" matches any sequence of characters.
+func matchPattern(pattern, path string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == '*' {
+			pattern = pattern[1:]
+			if pattern == "" {
+				return true
+			}
+			for i := range len(path) + 1 {
+				if matchPattern(pattern, path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if path == "" || pattern[0] != path[0] {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return path == ""
+}
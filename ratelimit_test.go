@@ -0,0 +1,51 @@
+package cc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// TestMatchPattern tests the matchPattern function.
+func TestMatchPattern(t *testing.T) {
+	assert.True(t, matchPattern("/dna/intent/api/v1/network-device*", "/dna/intent/api/v1/network-device"))
+	assert.True(t, matchPattern("/dna/intent/api/v1/network-device*", "/dna/intent/api/v1/network-device/123/config"))
+	assert.True(t, matchPattern("/dna/*/api/v1/site", "/dna/intent/api/v1/site"))
+	assert.False(t, matchPattern("/dna/intent/api/v1/network-device*", "/dna/intent/api/v1/site"))
+	assert.False(t, matchPattern("/dna/intent/api/v1/site", "/dna/intent/api/v1/site/123"))
+}
+
+// TestClientRateLimit tests that requests are paced by the rate limit of their path.
+func TestClientRateLimit(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+	WithRateLimit("/limited*", 2, 100*time.Millisecond)(client)
+
+	gock.New(testURL).Get("/limited").Times(4).Reply(200)
+	gock.New(testURL).Get("/unlimited").Times(4).Reply(200)
+
+	start := time.Now()
+	for range 4 {
+		_, err := client.Get("/unlimited")
+		assert.NoError(t, err)
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	start = time.Now()
+	for range 4 {
+		_, err := client.Get("/limited")
+		assert.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+// TestClientRateLimitInvalid tests that limits with invalid values are ignored.
+func TestClientRateLimitInvalid(t *testing.T) {
+	client := authenticatedTestClient()
+	WithRateLimit("", 0, time.Second)(client)
+	WithRateLimit("", -1, time.Second)(client)
+	WithRateLimit("", 10, 0)(client)
+	assert.Empty(t, client.rateLimiters)
+}
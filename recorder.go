@@ -0,0 +1,181 @@
+package cc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// exchange is an HTTP exchange seen by a recorder. The bodies are scrubbed of secrets, the headers are not.
+type exchange struct {
+	req     *http.Request
+	reqBody string
+	res     *http.Response
+	resBody string
+	start   time.Time
+	// wait is the time until the response headers were received, receive the time to read the response body.
+	wait, receive time.Duration
+}
+
+// recorder is a transport writing all HTTP exchanges to a file, shared by RecordCassette and WithHAR.
+// Recording never fails a request: errors are logged and recording stops.
+type recorder struct {
+	client *Client
+	next   http.RoundTripper
+	file   *jsonArrayFile
+	// entry converts an exchange to an entry of the file.
+	entry func(exchange) any
+	// closing arranges for closing the file with the client, which is only possible once NewClient returned.
+	closing sync.Once
+}
+
+// newRecorder wraps the transport of the client with a recorder. The file is closed when the client is closed.
+func newRecorder(client *Client, file *jsonArrayFile, entry func(exchange) any) *recorder {
+	return &recorder{client: client, next: transport(client.HttpClient), file: file, entry: entry}
+}
+
+// RoundTrip implements http.RoundTripper. The request body is read through GetBody, so the request is not modified.
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.closing.Do(func() { context.AfterFunc(r.client.ctx, r.file.close) })
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+	start := time.Now()
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	wait := time.Since(start)
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+	if err != nil {
+		return res, nil
+	}
+
+	err = r.file.append(r.entry(exchange{
+		req:     req,
+		reqBody: r.client.scrubPayload(string(reqBody)),
+		res:     res,
+		resBody: r.client.scrubPayload(string(resBody)),
+		start:   start,
+		wait:    wait,
+		receive: time.Since(start) - wait,
+	}))
+	if err != nil {
+		r.client.logger().ErrorContext(req.Context(), "Cannot record HTTP exchange", "path", r.file.path, "error", err)
+	}
+	return res, nil
+}
+
+// unwrap returns the wrapped transport.
+func (r *recorder) unwrap() http.RoundTripper {
+	return r.next
+}
+
+// transport returns the transport of the HTTP client.
+func transport(httpClient *http.Client) http.RoundTripper {
+	if httpClient.Transport == nil {
+		return http.DefaultTransport
+	}
+	return httpClient.Transport
+}
+
+// baseTransport returns the *http.Transport underneath any transports wrapping it, e.g. recorders.
+func baseTransport(rt http.RoundTripper) (*http.Transport, bool) {
+	for {
+		switch t := rt.(type) {
+		case *http.Transport:
+			return t, true
+		case interface{ unwrap() http.RoundTripper }:
+			rt = t.unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// scrubPayload redacts the RedactedPaths and the token of login responses.
+func (client *Client) scrubPayload(payload string) string {
+	payload = client.redactPayload(payload)
+	if gjson.Get(payload, "Token").Exists() {
+		if s, err := sjson.Set(payload, "Token", redacted); err == nil {
+			payload = s
+		}
+	}
+	return payload
+}
+
+// jsonArrayFile is a JSON file with an array of entries enclosed by a fixed prefix and suffix, e.g.
+// `{"entries": [` and `]}`. Entries are appended by overwriting the suffix, so that the file is valid JSON after
+// every entry without rewriting it. The file is created on the first entry.
+type jsonArrayFile struct {
+	path           string
+	prefix, suffix string
+	mu             sync.Mutex
+	f              *os.File
+	entries        int
+	err            error
+}
+
+// append writes an entry to the file. After an error, no more entries are written.
+func (j *jsonArrayFile) append(entry any) error {
+	data, err := json.MarshalIndent(entry, "    ", "  ")
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.err != nil {
+		return j.err
+	}
+	if j.f == nil {
+		if j.f, j.err = os.OpenFile(j.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600); j.err != nil {
+			return j.err
+		}
+		if _, j.err = j.f.WriteString(j.prefix + "\n"); j.err != nil {
+			return j.err
+		}
+	}
+	var buf bytes.Buffer
+	if j.entries > 0 {
+		buf.WriteString(",\n")
+	}
+	buf.WriteString("    ")
+	buf.Write(data)
+	buf.WriteString("\n" + j.suffix + "\n")
+	if _, j.err = j.f.Write(buf.Bytes()); j.err != nil {
+		return j.err
+	}
+	// The next entry overwrites the trailing newline and suffix.
+	if _, j.err = j.f.Seek(-int64(len(j.suffix)+2), io.SeekCurrent); j.err != nil {
+		return j.err
+	}
+	j.entries++
+	return nil
+}
+
+// close closes the file, no more entries are written afterwards.
+func (j *jsonArrayFile) close() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f != nil {
+		j.f.Close()
+	}
+	if j.err == nil {
+		j.err = errors.New("recording closed")
+	}
+}
@@ -54,12 +54,30 @@ type Req struct {
 	Synchronous bool
 	// MaxAsyncWaitTime is the maximum time to wait for an asynchronous operation.
 	MaxAsyncWaitTime int
+	// PollPolicy defines how the asynchronous operation is polled
+	PollPolicy PollPolicy
 	// NoWait indicates whether to wait for the task or not. If True, the WaitTask function will not be executed.
 	NoWait bool
 	// UseMutex indicates whether to use the writingMutex for this request
 	UseMutex bool
 	// ReAuthAttempted indicates whether request already tried to reauthenticate in case of 401
 	ReAuthAttempted bool
+	// RetryPolicy overrides the RetryPolicy of the client for this request
+	RetryPolicy RetryPolicy
+	// PageSize is the number of items per page of a paginated GET, zero means detection from the path or 500
+	PageSize int
+	// Pagination is the pagination style of a paginated GET
+	Pagination PaginationStyle
+	// VerifyPagesKey is the GJSON path of the item key used to verify the consistency of pages
+	VerifyPagesKey string
+	// ParallelPages is the maximum number of pages of a paginated GET which are fetched concurrently
+	ParallelPages int
+	// CountPath is the path of the count API of a paginated GET, e.g. "/dna/intent/api/v1/network-device/count"
+	CountPath string
+	// OnTaskProgress is called with the status of the asynchronous task on every poll
+	OnTaskProgress func(TaskStatus)
+	// WaitTaskTree indicates whether to wait for all descendants of the asynchronous task
+	WaitTaskTree bool
 }
 
 // NoLogPayload prevents logging of payloads.
@@ -76,9 +94,11 @@ func Asynchronous(req *Req) {
 
 // Maximum Asynchronous operation wait time.
 // This is only relevant for POST, PUT or DELETE requests.
+// It overrides the MaxDuration of the PollPolicy of the client, or of an earlier UsePollPolicy.
 func MaxAsyncWaitTime(seconds int) func(*Req) {
 	return func(req *Req) {
 		req.MaxAsyncWaitTime = seconds
+		req.PollPolicy.MaxDuration = 0
 	}
 }
 
@@ -91,3 +111,52 @@ func NoWait(req *Req) {
 func UseMutex(req *Req) {
 	req.UseMutex = true
 }
+
+// UseRetryPolicy overrides the RetryPolicy of the client for this request.
+func UseRetryPolicy(policy RetryPolicy) func(*Req) {
+	return func(req *Req) {
+		req.RetryPolicy = policy
+	}
+}
+
+// PageSize sets the number of items per page of a paginated GET and sends it as "limit" or "pageSize" query parameter.
+// By default, the page size is taken from the query parameters of the path, from RegisterPageSize or is 500.
+func PageSize(n int) func(*Req) {
+	return func(req *Req) {
+		req.PageSize = n
+	}
+}
+
+// Pagination sets the pagination style of a GET, by default it is detected from the path.
+func Pagination(style PaginationStyle) func(*Req) {
+	return func(req *Req) {
+		req.Pagination = style
+	}
+}
+
+// VerifyPages verifies the consistency of the pages of a paginated GET by the key of the items, e.g. "id".
+// If an item is received twice or the total count does not match, the pagination is restarted.
+// Items skipped because they shifted to an earlier page are only detected by the total count, so without a
+// "totalCount" in the page metadata, only duplicates are detected.
+func VerifyPages(key string) func(*Req) {
+	return func(req *Req) {
+		req.VerifyPagesKey = key
+	}
+}
+
+// ParallelPages fetches up to n pages of a paginated GET concurrently. This requires the total number of items,
+// either as "totalCount" in the first page or from the count API set by CountPath. Otherwise pages are fetched
+// sequentially.
+func ParallelPages(n int) func(*Req) {
+	return func(req *Req) {
+		req.ParallelPages = n
+	}
+}
+
+// CountPath sets the path of the count API returning the total number of items of a paginated GET in its
+// "response" attribute, e.g. "/dna/intent/api/v1/network-device/count". It is used by ParallelPages.
+func CountPath(path string) func(*Req) {
+	return func(req *Req) {
+		req.CountPath = path
+	}
+}
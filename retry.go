@@ -0,0 +1,118 @@
+package cc
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy decides whether a failed request attempt is retried and how long to wait before the next attempt.
+// Set it for the whole client with WithRetryPolicy or for a single request with UseRetryPolicy.
+type RetryPolicy interface {
+	// Retry is called after every failed attempt, attempt is counted from zero.
+	// If err is set, no response could be received or read and httpRes may be nil.
+	// Otherwise httpRes is the non-2xx response and res its parsed body.
+	Retry(req *Req, attempt int, httpRes *http.Response, res Res, err error) (bool, time.Duration)
+}
+
+// RetryPolicyFunc is an adapter to allow the use of ordinary functions as a RetryPolicy.
+type RetryPolicyFunc func(req *Req, attempt int, httpRes *http.Response, res Res, err error) (bool, time.Duration)
+
+// Retry calls f(req, attempt, httpRes, res, err).
+func (f RetryPolicyFunc) Retry(req *Req, attempt int, httpRes *http.Response, res Res, err error) (bool, time.Duration) {
+	return f(req, attempt, httpRes, res, err)
+}
+
+// DefaultRetryPolicy is the RetryPolicy used when none is configured. It retries connection errors and
+// the status codes 408, 429 and 502-504 with exponential backoff, honoring the Retry-After header of 429 responses.
+// Custom policies can embed it to only override specific cases, e.g.
+//
+//	cc.RetryPolicyFunc(func(req *cc.Req, attempt int, httpRes *http.Response, res cc.Res, err error) (bool, time.Duration) {
+//		if req.HttpReq.Method == "POST" {
+//			return false, 0
+//		}
+//		return client.DefaultRetryPolicy().Retry(req, attempt, httpRes, res, err)
+//	})
+type DefaultRetryPolicy struct {
+	// Maximum number of retries
+	MaxRetries int
+	// Minimum delay between two retries in seconds
+	BackoffMinDelay int
+	// Maximum delay between two retries in seconds
+	BackoffMaxDelay int
+	// Backoff delay factor
+	BackoffDelayFactor float64
+}
+
+// Retry implements RetryPolicy.
+func (p DefaultRetryPolicy) Retry(req *Req, attempt int, httpRes *http.Response, res Res, err error) (bool, time.Duration) {
+	if attempt >= p.MaxRetries {
+		return false, 0
+	}
+	delay := backoffDelay(attempt, p.BackoffMinDelay, p.BackoffMaxDelay, p.BackoffDelayFactor)
+	if err != nil {
+		return true, delay
+	}
+
+	switch {
+	case httpRes.StatusCode == 429:
+		return true, delay + retryAfter(httpRes)
+	case httpRes.StatusCode == 408 || (httpRes.StatusCode >= 502 && httpRes.StatusCode <= 504):
+		return true, delay
+	}
+	return false, 0
+}
+
+// WithRetryPolicy sets the RetryPolicy used for all requests of the client.
+func WithRetryPolicy(policy RetryPolicy) func(*Client) {
+	return func(client *Client) {
+		client.RetryPolicy = policy
+	}
+}
+
+// DefaultRetryPolicy returns the DefaultRetryPolicy built from the retry settings of the client.
+func (client *Client) DefaultRetryPolicy() DefaultRetryPolicy {
+	return DefaultRetryPolicy{
+		MaxRetries:         client.MaxRetries,
+		BackoffMinDelay:    client.BackoffMinDelay,
+		BackoffMaxDelay:    client.BackoffMaxDelay,
+		BackoffDelayFactor: client.BackoffDelayFactor,
+	}
+}
+
+// retryPolicy returns the RetryPolicy effective for the request.
+func (client *Client) retryPolicy(req *Req) RetryPolicy {
+	if req.RetryPolicy != nil {
+		return req.RetryPolicy
+	}
+	if client.RetryPolicy != nil {
+		return client.RetryPolicy
+	}
+	return client.DefaultRetryPolicy()
+}
+
+// backoffDelay computes a randomized exponential backoff delay, with minDelay and maxDelay in seconds.
+func backoffDelay(attempts, minDelay, maxDelay int, factor float64) time.Duration {
+	min := float64(time.Duration(minDelay) * time.Second)
+	max := float64(time.Duration(maxDelay) * time.Second)
+
+	backoff := min * math.Pow(factor, float64(attempts))
+	if backoff > max {
+		backoff = max
+	}
+	backoff = (rand.Float64()/2+0.5)*(backoff-min) + min
+	return time.Duration(backoff)
+}
+
+// retryAfter returns the delay requested by the Retry-After header of a 429 response.
+func retryAfter(httpRes *http.Response) time.Duration {
+	retryAfter := httpRes.Header.Get("Retry-After")
+	if retryAfter == "0" {
+		return time.Second
+	} else if retryAfter != "" {
+		d, _ := time.ParseDuration(retryAfter + "s")
+		return d
+	}
+	return 15 * time.Second
+}
@@ -0,0 +1,57 @@
+package cc
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// TestDefaultRetryPolicy tests the DefaultRetryPolicy.Retry method.
+func TestDefaultRetryPolicy(t *testing.T) {
+	policy := DefaultRetryPolicy{MaxRetries: 2, BackoffMinDelay: 1, BackoffMaxDelay: 1, BackoffDelayFactor: 1}
+
+	retry, delay := policy.Retry(&Req{}, 0, &http.Response{StatusCode: 503}, Res{}, nil)
+	assert.True(t, retry)
+	assert.Equal(t, time.Second, delay)
+
+	retry, _ = policy.Retry(&Req{}, 0, &http.Response{StatusCode: 500}, Res{}, nil)
+	assert.False(t, retry)
+
+	retry, _ = policy.Retry(&Req{}, 2, &http.Response{StatusCode: 503}, Res{}, nil)
+	assert.False(t, retry)
+
+	retry, delay = policy.Retry(&Req{}, 0, &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": []string{"3"}}}, Res{}, nil)
+	assert.True(t, retry)
+	assert.Equal(t, 4*time.Second, delay)
+}
+
+// TestClientRetryPolicy tests custom retry policies on the client and on a single request.
+func TestClientRetryPolicy(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+	WithRetryPolicy(RetryPolicyFunc(func(req *Req, attempt int, httpRes *http.Response, res Res, err error) (bool, time.Duration) {
+		if httpRes != nil && strings.Contains(res.Get("message").String(), "NCND00050") {
+			return attempt < 1, 0
+		}
+		return false, 0
+	}))(client)
+
+	// Retried by the client policy
+	gock.New(testURL).Get("/url").Reply(500).BodyString(`{"message":"NCND00050: resource busy"}`)
+	gock.New(testURL).Get("/url").Reply(200).BodyString(`{"response":"ok"}`)
+	res, err := client.Get("/url")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Get("response").String())
+
+	// Not retried by the request policy
+	gock.New(testURL).Post("/url").Reply(500).BodyString(`{"message":"NCND00050: resource busy"}`)
+	_, err = client.Post("/url", "{}", UseRetryPolicy(RetryPolicyFunc(func(*Req, int, *http.Response, Res, error) (bool, time.Duration) {
+		return false, 0
+	})))
+	assert.Error(t, err)
+	assert.True(t, gock.IsDone())
+}
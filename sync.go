@@ -0,0 +1,81 @@
+package cc
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syncEndpoints is the registry of endpoints executed synchronously by Catalyst Center, in addition to
+// SynchronousApiEndpoints.
+var syncEndpoints struct {
+	mu       sync.RWMutex
+	patterns []string
+}
+
+// RegisterSyncEndpoint opts the endpoints matching the pattern into synchronous execution: POST, PUT and DELETE
+// requests to them are sent with the "__runsync" header, so that Catalyst Center responds once the operation
+// completed instead of returning a task, and with "__runsynctimeout" set to the time the request would wait for
+// a task, i.e. its MaxAsyncWaitTime or the MaxDuration of its PollPolicy.
+//
+// The pattern is matched against the path without query. A segment in braces matches any single path segment
+// and "*" matches any sequence of characters within a segment, e.g.
+//
+//	cc.RegisterSyncEndpoint("/dna/intent/api/v1/site/{id}")
+//	cc.RegisterSyncEndpoint("/dna/intent/api/v1/*-pool")
+//
+// The registry is shared by all clients. SynchronousApiEndpoints are always executed synchronously.
+func RegisterSyncEndpoint(pattern string) {
+	syncEndpoints.mu.Lock()
+	defer syncEndpoints.mu.Unlock()
+	syncEndpoints.patterns = append(syncEndpoints.patterns, pattern)
+}
+
+// isSyncEndpoint reports whether the uri matches a registered synchronous endpoint.
+func isSyncEndpoint(uri string) bool {
+	path, _, _ := strings.Cut(uri, "?")
+	for _, pattern := range SynchronousApiEndpoints {
+		if matchTemplate(pattern, path) {
+			return true
+		}
+	}
+	syncEndpoints.mu.RLock()
+	defer syncEndpoints.mu.RUnlock()
+	for _, pattern := range syncEndpoints.patterns {
+		if matchTemplate(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTemplate reports whether the path matches the path template, segment by segment.
+func matchTemplate(template, path string) bool {
+	templateSegments := strings.Split(strings.TrimSuffix(template, "/"), "/")
+	pathSegments := strings.Split(strings.TrimSuffix(path, "/"), "/")
+	if len(templateSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range templateSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if !matchPattern(segment, pathSegments[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// setSync marks the request for synchronous execution.
+func (req *Req) setSync() {
+	req.HttpReq.Header.Set("__runsync", "true")
+	if wait := req.maxWait(); wait > 0 {
+		// The same deadline as for polling, rounded up to whole seconds.
+		req.HttpReq.Header.Set("__runsynctimeout", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+	}
+}
@@ -0,0 +1,46 @@
+package cc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMatchTemplate tests the matchTemplate function.
+func TestMatchTemplate(t *testing.T) {
+	assert.True(t, matchTemplate("/dna/intent/api/v1/site", "/dna/intent/api/v1/site"))
+	assert.True(t, matchTemplate("/dna/intent/api/v1/site", "/dna/intent/api/v1/site/"))
+	assert.True(t, matchTemplate("/dna/intent/api/v1/site/{id}", "/dna/intent/api/v1/site/123"))
+	assert.False(t, matchTemplate("/dna/intent/api/v1/site/{id}", "/dna/intent/api/v1/site"))
+	assert.False(t, matchTemplate("/dna/intent/api/v1/site/{id}", "/dna/intent/api/v1/site/123/device"))
+	assert.True(t, matchTemplate("/dna/intent/api/v1/*-pool", "/dna/intent/api/v1/global-pool"))
+	assert.False(t, matchTemplate("/dna/intent/api/v1/*-pool", "/dna/intent/api/v1/global/pool"))
+}
+
+// TestNewReqSync tests the synchronous execution headers set by NewReq.
+func TestNewReqSync(t *testing.T) {
+	client := testClient()
+
+	req := client.NewReq("POST", "/dna/intent/api/v1/site?foo=bar", nil, MaxAsyncWaitTime(60))
+	assert.Equal(t, "true", req.HttpReq.Header.Get("__runsync"))
+	assert.Equal(t, "60", req.HttpReq.Header.Get("__runsynctimeout"))
+
+	req = client.NewReq("GET", "/dna/intent/api/v1/site", nil)
+	assert.Empty(t, req.HttpReq.Header.Get("__runsync"))
+
+	req = client.NewReq("DELETE", "/test/sync/123", nil)
+	assert.Empty(t, req.HttpReq.Header.Get("__runsync"))
+	patterns := syncEndpoints.patterns
+	t.Cleanup(func() { syncEndpoints.patterns = patterns })
+	RegisterSyncEndpoint("/test/sync/{id}")
+	req = client.NewReq("DELETE", "/test/sync/123", nil)
+	assert.Equal(t, "true", req.HttpReq.Header.Get("__runsync"))
+	assert.Equal(t, "30", req.HttpReq.Header.Get("__runsynctimeout"))
+
+	// Changes to SynchronousApiEndpoints take effect immediately.
+	defaults := SynchronousApiEndpoints
+	t.Cleanup(func() { SynchronousApiEndpoints = defaults })
+	SynchronousApiEndpoints[1] = "/test/default"
+	req = client.NewReq("PUT", "/test/default", nil)
+	assert.Equal(t, "true", req.HttpReq.Header.Get("__runsync"))
+}
@@ -0,0 +1,316 @@
+package cc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// TaskKind is the kind of an asynchronous operation.
+type TaskKind string
+
+const (
+	// TaskKindTask is a task, polled via /api/v1/task/{id}.
+	TaskKindTask TaskKind = "task"
+	// TaskKindExecution is a business API execution, polled via
+	// /dna/platform/management/business-api/v1/execution-status/{id}.
+	TaskKindExecution TaskKind = "execution"
+)
+
+// TaskHandle identifies an asynchronous task or execution. It can be serialized, e.g. with encoding/json,
+// to resume waiting for the task later or in another process, e.g.
+//
+//	res, _ := client.Post("/dna/intent/api/v1/business/sda/provision-device", body, cc.NoWait)
+//	handle, _ := cc.NewTaskHandle(res)
+//	// persist handle, restart, load handle
+//	res, err := client.WaitTaskID(ctx, handle.Kind, handle.ID)
+type TaskHandle struct {
+	// Kind is the kind of the asynchronous operation.
+	Kind TaskKind `json:"kind"`
+	// ID is the ID of the task or execution.
+	ID string `json:"id"`
+}
+
+// NewTaskHandle returns the handle of the asynchronous task or execution started by a request, given its response.
+// It reports false if the response does not refer to a task or execution.
+func NewTaskHandle(res Res) (TaskHandle, bool) {
+	if id := res.Get("response.taskId"); id.Exists() {
+		return TaskHandle{Kind: TaskKindTask, ID: id.String()}, true
+	}
+	if id := res.Get("executionId"); id.Exists() {
+		return TaskHandle{Kind: TaskKindExecution, ID: id.String()}, true
+	}
+	return TaskHandle{}, false
+}
+
+// WaitTaskID waits for the asynchronous task or execution id to complete, e.g. one started with NoWait.
+// Request modifiers like MaxAsyncWaitTime, UsePollPolicy or WaitTaskTree apply to the wait.
+func (client *Client) WaitTaskID(ctx context.Context, kind TaskKind, id string, mods ...func(*Req)) (Res, error) {
+	if kind != TaskKindTask && kind != TaskKindExecution {
+		return Res{}, fmt.Errorf("unknown task kind '%s'", kind)
+	}
+	if client.ctx.Err() != nil {
+		return Res{}, ErrClientClosed
+	}
+	req := client.NewReq("GET", "", nil, mods...)
+	ctx, cancel := client.bind(ctx)
+	defer cancel()
+	if err := client.AuthenticateCtx(ctx); err != nil {
+		return Res{}, closedErr(ctx, err)
+	}
+	res, err := client.waitTaskID(ctx, &req, kind, id)
+	return res, closedErr(ctx, err)
+}
+
+// TaskStatus is the status of an asynchronous task or execution, as returned by a single poll.
+type TaskStatus struct {
+	// ID is the ID of the task or execution.
+	ID string
+	// ParentID is the ID of the parent task, empty for a root task or an execution.
+	ParentID string
+	// Progress is the progress message of a task or the status of an execution, e.g. "IN_PROGRESS".
+	Progress string
+	// IsError indicates that the task or execution failed.
+	IsError bool
+	// FailureReason is the failure reason of a failed task or the error of a failed execution.
+	FailureReason string
+	// StartTime is the start time, zero if unknown.
+	StartTime time.Time
+	// EndTime is the end time, zero if the task or execution is not completed.
+	EndTime time.Time
+	// Data is the data attribute of a task.
+	Data string
+	// ServiceType is the service type of a task.
+	ServiceType string
+	// Res is the raw status response.
+	Res Res
+}
+
+// Done reports whether the task or execution is completed, successfully or not.
+func (s TaskStatus) Done() bool {
+	return s.IsError || !s.EndTime.IsZero()
+}
+
+// OnTaskProgress calls the func with the status of the asynchronous task or execution on every poll, e.g.
+//
+//	client.Post("/dna/intent/api/v1/image/distribution", body, cc.OnTaskProgress(func(s cc.TaskStatus) {
+//		fmt.Printf("%s: %s\n", s.ID, s.Progress)
+//	}))
+func OnTaskProgress(f func(TaskStatus)) func(*Req) {
+	return func(req *Req) {
+		req.OnTaskProgress = f
+	}
+}
+
+// WaitTaskTree waits for all descendants of an asynchronous task, not only the task itself, e.g. for
+// provisioning or template deployment workflows that spawn child tasks. Failures of descendants are
+// aggregated into the returned TaskError.
+func WaitTaskTree(req *Req) {
+	req.WaitTaskTree = true
+}
+
+// GetTaskTree retrieves the status of a task and all its descendants.
+func (client *Client) GetTaskTree(id string) ([]TaskStatus, error) {
+	return client.GetTaskTreeCtx(context.Background(), id)
+}
+
+// GetTaskTreeCtx retrieves the status of a task and all its descendants, using the provided context.
+func (client *Client) GetTaskTreeCtx(ctx context.Context, id string) ([]TaskStatus, error) {
+	res, err := client.get(ctx, "/api/v1/task/"+id+"/tree")
+	if err != nil {
+		return nil, err
+	}
+	return parseTaskTree(res), nil
+}
+
+// parseTaskTree parses the response of the task tree API.
+func parseTaskTree(res Res) []TaskStatus {
+	var tree []TaskStatus
+	res.Get("response").ForEach(func(_, task gjson.Result) bool {
+		tree = append(tree, parseTask(task.Get("id").String(), task, res))
+		return true
+	})
+	return tree
+}
+
+// newTaskStatus parses the response of the task or execution status API.
+func newTaskStatus(kind TaskKind, id string, res Res) TaskStatus {
+	if kind == TaskKindTask {
+		return parseTask(id, res.Get("response"), res)
+	}
+
+	status := TaskStatus{ID: id, Res: res}
+	status.Progress = res.Get("status").String()
+	status.IsError = status.Progress == "FAILURE"
+	status.FailureReason = res.Get("bapiError").String()
+	status.StartTime = parseTime(res.Get("startTime"))
+	status.EndTime = parseTime(res.Get("endTime"))
+	if status.EndTime.IsZero() && status.Progress == "SUCCESS" {
+		status.EndTime = time.Now()
+	}
+	return status
+}
+
+// parseTask parses a single task object of the task or task tree API.
+func parseTask(id string, task gjson.Result, res Res) TaskStatus {
+	status := TaskStatus{ID: id, ParentID: task.Get("parentId").String(), Res: res}
+	status.Progress = task.Get("progress").String()
+	status.IsError = task.Get("isError").Bool()
+	status.FailureReason = task.Get("failureReason").String()
+	status.StartTime = parseTime(task.Get("startTime"))
+	status.EndTime = parseTime(task.Get("endTime"))
+	status.Data = task.Get("data").String()
+	status.ServiceType = task.Get("serviceType").String()
+	if status.EndTime.IsZero() && task.Get("endTime").Exists() {
+		// Completed, but with an unparsable end time.
+		status.EndTime = time.Now()
+	}
+	return status
+}
+
+// parseTime parses a timestamp in epoch milliseconds or RFC 3339 format.
+func parseTime(t gjson.Result) time.Time {
+	switch t.Type {
+	case gjson.Number:
+		return time.UnixMilli(t.Int())
+	case gjson.String:
+		if ms := gjson.Parse(t.Str); ms.Type == gjson.Number {
+			return time.UnixMilli(ms.Int())
+		}
+		if parsed, err := time.Parse(time.RFC3339, t.Str); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+// waitTaskTree waits for all descendants of the completed task id and aggregates their failures.
+func (client *Client) waitTaskTree(ctx context.Context, req *Req, id string, p *poller, taskRes Res) (Res, error) {
+	logger := client.logger().With("task_id", id)
+	for attempts := 0; ; attempts++ {
+		if attempts > 0 {
+			if err := p.wait(ctx); err != nil {
+				return Res{}, err
+			}
+		}
+		res, err := client.get(ctx, "/api/v1/task/"+id+"/tree")
+		if err != nil {
+			return res, err
+		}
+		var pending int
+		var failed []TaskStatus
+		for _, status := range parseTaskTree(res) {
+			if status.ID != id && req.OnTaskProgress != nil {
+				req.OnTaskProgress(status)
+			}
+			if !status.Done() {
+				pending++
+			}
+			if status.IsError {
+				failed = append(failed, status)
+			}
+		}
+		if pending == 0 {
+			if len(failed) == 0 {
+				return taskRes, nil
+			}
+			reasons := make([]string, len(failed))
+			for i, status := range failed {
+				reasons[i] = fmt.Sprintf("'%s': %s", status.ID, status.FailureReason)
+			}
+			logger.ErrorContext(ctx, "Child tasks failed", "failed", len(failed), "duration", p.elapsed())
+			return res, &TaskError{TaskID: id, FailureReason: strings.Join(reasons, "; "), Res: res, Failed: failed}
+		}
+		logger.DebugContext(ctx, "Waiting for child tasks to complete", "attempt", attempts, "pending", pending)
+		if p.expired() {
+			logger.DebugContext(ctx, "Maximum waiting time reached for task tree", "duration", p.elapsed())
+			return res, &TimeoutError{TaskID: id, Timeout: p.timeout, Res: res}
+		}
+	}
+}
+
+// TaskResult is the result of a successfully completed task or execution, extracted from the
+// common shapes of the status responses.
+type TaskResult struct {
+	// ID is the ID of the created or affected object, if reported, e.g. the "id" of a JSON progress
+	// or the data attribute of a task.
+	ID string
+	// Payload is the parsed JSON result, i.e. the progress of a task if it contains JSON or the
+	// bapiSyncResponse of an execution.
+	Payload Res
+	// Progress is the progress message of a task.
+	Progress string
+	// Data is the data attribute of a task.
+	Data string
+	// FileID is the ID of a file holding the result, e.g. the output of the command runner.
+	FileID string
+	// AdditionalStatusURL is the URL of further status information of a task.
+	AdditionalStatusURL string
+	// ResultURL is the URL of the result of an execution, i.e. the "resultUrl" of the execution status.
+	ResultURL string
+	// Res is the raw status response.
+	Res Res
+}
+
+// NewTaskResult extracts the result of a task or execution from the response returned by WaitTask, e.g.
+//
+//	res, _ := client.Post("/dna/intent/api/v1/template-programmer/project", body)
+//	projectID := cc.NewTaskResult(res).ID
+func NewTaskResult(res Res) TaskResult {
+	result := TaskResult{Res: res}
+	if task := res.Get("response"); task.IsObject() {
+		result.Progress = task.Get("progress").String()
+		result.Data = task.Get("data").String()
+		result.AdditionalStatusURL = task.Get("additionalStatusURL").String()
+		result.Payload = parseEmbeddedJSON(result.Progress)
+	} else {
+		result.ResultURL = res.Get("resultUrl").String()
+		result.Payload = res.Get("bapiSyncResponseJson")
+		if !result.Payload.IsObject() && !result.Payload.IsArray() {
+			result.Payload = parseEmbeddedJSON(res.Get("bapiSyncResponse").String())
+		}
+	}
+	result.FileID = result.Payload.Get("fileId").String()
+	result.ID = result.Payload.Get("id").String()
+	if result.ID == "" && !parseEmbeddedJSON(result.Data).Exists() {
+		result.ID = result.Data
+	}
+	return result
+}
+
+// parseEmbeddedJSON parses a string attribute holding a JSON object or array, returning an empty result otherwise.
+func parseEmbeddedJSON(s string) Res {
+	s = strings.TrimSpace(s)
+	if (strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")) && gjson.Valid(s) {
+		return gjson.Parse(s)
+	}
+	return Res{}
+}
+
+// FollowTaskResult retrieves the resource a task result points to: the additional status URL, if any,
+// otherwise the result URL of an execution or the file holding the result. The response must be JSON.
+// It returns an error if the result points to none of them.
+func (client *Client) FollowTaskResult(ctx context.Context, result TaskResult) (Res, error) {
+	switch {
+	case result.AdditionalStatusURL != "":
+		return client.followURL(ctx, "additional status", result.AdditionalStatusURL)
+	case result.ResultURL != "":
+		return client.followURL(ctx, "result", result.ResultURL)
+	case result.FileID != "":
+		return client.get(ctx, "/dna/intent/api/v1/file/"+result.FileID)
+	}
+	return Res{}, errors.New("task result has no additional status URL, result URL or file ID")
+}
+
+// followURL retrieves a URL of a task result, which is either a path or an absolute URL on the client URL.
+func (client *Client) followURL(ctx context.Context, name, url string) (Res, error) {
+	path := strings.TrimPrefix(url, client.Url)
+	if !strings.HasPrefix(path, "/") {
+		return Res{}, fmt.Errorf("%s URL '%s' is not on %s", name, url, client.Url)
+	}
+	return client.get(ctx, path)
+}
@@ -0,0 +1,197 @@
+package cc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// TestClientOnTaskProgress tests the OnTaskProgress request modifier.
+func TestClientOnTaskProgress(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	gock.New(testURL).Post("/url").Reply(202).BodyString(`{"response": {"taskId": "123"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).
+		BodyString(`{"response": {"taskId": "123", "progress": "running", "isError": false, "startTime": 1700000000000, "serviceType": "Ncsp"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).
+		BodyString(`{"response": {"taskId": "123", "progress": "done", "isError": false, "startTime": 1700000000000, "endTime": 1700000001000, "data": "abc"}}`)
+
+	var statuses []TaskStatus
+	_, err := client.Post("/url", "{}", OnTaskProgress(func(s TaskStatus) {
+		statuses = append(statuses, s)
+	}))
+	assert.NoError(t, err)
+	if assert.Len(t, statuses, 2) {
+		assert.Equal(t, "123", statuses[0].ID)
+		assert.Equal(t, "running", statuses[0].Progress)
+		assert.Equal(t, "Ncsp", statuses[0].ServiceType)
+		assert.Equal(t, time.UnixMilli(1700000000000), statuses[0].StartTime)
+		assert.False(t, statuses[0].Done())
+		assert.Equal(t, "done", statuses[1].Progress)
+		assert.Equal(t, "abc", statuses[1].Data)
+		assert.Equal(t, time.UnixMilli(1700000001000), statuses[1].EndTime)
+		assert.True(t, statuses[1].Done())
+	}
+}
+
+// TestNewTaskStatus tests parsing the execution status.
+func TestNewTaskStatus(t *testing.T) {
+	status := newTaskStatus("execution", "E1", Res{Raw: `{"status": "FAILURE", "bapiError": "bad", "startTime": 1700000000000, "endTime": 1700000001000}`})
+	assert.Equal(t, "FAILURE", status.Progress)
+	assert.True(t, status.IsError)
+	assert.Equal(t, "bad", status.FailureReason)
+	assert.True(t, status.Done())
+}
+
+// TestClientGetTaskTree tests the Client::GetTaskTree method.
+func TestClientGetTaskTree(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	gock.New(testURL).Get("/api/v1/task/123/tree").Reply(200).
+		BodyString(`{"response": [{"id": "123", "progress": "done", "endTime": 1700000001000}, {"id": "456", "parentId": "123", "progress": "running"}]}`)
+
+	tree, err := client.GetTaskTree("123")
+	assert.NoError(t, err)
+	if assert.Len(t, tree, 2) {
+		assert.Equal(t, "123", tree[0].ID)
+		assert.True(t, tree[0].Done())
+		assert.Equal(t, "456", tree[1].ID)
+		assert.Equal(t, "123", tree[1].ParentID)
+		assert.False(t, tree[1].Done())
+	}
+}
+
+// TestClientWaitTaskTree tests the WaitTaskTree request modifier.
+func TestClientWaitTaskTree(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	// Tree mocks first, the task mock would also match the tree path
+	gock.New(testURL).Get("/api/v1/task/123/tree").Reply(200).
+		BodyString(`{"response": [{"id": "123", "endTime": 1700000001000}, {"id": "456", "parentId": "123"}, {"id": "789", "parentId": "123", "isError": true, "failureReason": "bad image"}]}`)
+	gock.New(testURL).Get("/api/v1/task/123/tree").Reply(200).
+		BodyString(`{"response": [{"id": "123", "endTime": 1700000001000}, {"id": "456", "parentId": "123", "isError": true, "failureReason": "unreachable"}, {"id": "789", "parentId": "123", "isError": true, "failureReason": "bad image"}]}`)
+	gock.New(testURL).Post("/url").Reply(202).BodyString(`{"response": {"taskId": "123"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"isError": false, "endTime": 1700000001000}}`)
+
+	var children []string
+	_, err := client.Post("/url", "{}", WaitTaskTree, OnTaskProgress(func(s TaskStatus) {
+		if s.ParentID != "" {
+			children = append(children, s.ID)
+		}
+	}))
+	var taskErr *TaskError
+	if assert.ErrorAs(t, err, &taskErr) {
+		assert.Equal(t, "123", taskErr.TaskID)
+		assert.Equal(t, "'456': unreachable; '789': bad image", taskErr.FailureReason)
+		assert.Len(t, taskErr.Failed, 2)
+	}
+	assert.Equal(t, []string{"456", "789", "456", "789"}, children)
+	assert.True(t, gock.IsDone())
+
+	// Without WaitTaskTree only the parent task is considered
+	gock.New(testURL).Post("/url").Reply(202).BodyString(`{"response": {"taskId": "123"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"isError": false, "endTime": 1700000001000}}`)
+	_, err = client.Post("/url", "{}")
+	assert.NoError(t, err)
+}
+
+// TestNewTaskHandle tests the NewTaskHandle function and the serialization of TaskHandle.
+func TestNewTaskHandle(t *testing.T) {
+	handle, ok := NewTaskHandle(Res{Raw: `{"response": {"taskId": "123"}}`})
+	assert.True(t, ok)
+	assert.Equal(t, TaskHandle{Kind: TaskKindTask, ID: "123"}, handle)
+
+	handle, ok = NewTaskHandle(Res{Raw: `{"executionId": "E1"}`})
+	assert.True(t, ok)
+	assert.Equal(t, TaskHandle{Kind: TaskKindExecution, ID: "E1"}, handle)
+
+	_, ok = NewTaskHandle(Res{Raw: `{"response": {}}`})
+	assert.False(t, ok)
+
+	data, err := json.Marshal(handle)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"kind": "execution", "id": "E1"}`, string(data))
+	var resumed TaskHandle
+	assert.NoError(t, json.Unmarshal(data, &resumed))
+	assert.Equal(t, handle, resumed)
+}
+
+// TestClientWaitTaskID tests the Client::WaitTaskID method.
+func TestClientWaitTaskID(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"progress": "running"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"progress": "done", "endTime": 1700000001000}}`)
+	res, err := client.WaitTaskID(context.Background(), TaskKindTask, "123")
+	assert.NoError(t, err)
+	assert.Equal(t, "done", res.Get("response.progress").String())
+
+	gock.New(testURL).Get("/dna/platform/management/business-api/v1/execution-status/E1").Reply(200).BodyString(`{"status": "FAILURE", "bapiError": "bad"}`)
+	_, err = client.WaitTaskID(context.Background(), TaskKindExecution, "E1")
+	var taskErr *TaskError
+	if assert.ErrorAs(t, err, &taskErr) {
+		assert.Equal(t, "E1", taskErr.TaskID)
+	}
+
+	_, err = client.WaitTaskID(context.Background(), "job", "1")
+	assert.EqualError(t, err, "unknown task kind 'job'")
+	assert.True(t, gock.IsDone())
+}
+
+// TestNewTaskResult tests extracting results from task and execution status responses.
+func TestNewTaskResult(t *testing.T) {
+	result := NewTaskResult(Res{Raw: `{"response": {"progress": "{\"fileId\": \"F1\"}", "endTime": 1700000001000}}`})
+	assert.Equal(t, "F1", result.FileID)
+	assert.Equal(t, "F1", result.Payload.Get("fileId").String())
+
+	result = NewTaskResult(Res{Raw: `{"response": {"progress": "Successfully created project", "data": "P1", "additionalStatusURL": "/dna/intent/api/v1/template-programmer/project/P1"}}`})
+	assert.Equal(t, "P1", result.ID)
+	assert.Equal(t, "Successfully created project", result.Progress)
+	assert.False(t, result.Payload.Exists())
+	assert.Equal(t, "/dna/intent/api/v1/template-programmer/project/P1", result.AdditionalStatusURL)
+
+	result = NewTaskResult(Res{Raw: `{"status": "SUCCESS", "bapiSyncResponse": "{\"id\": \"S1\", \"name\": \"site\"}"}`})
+	assert.Equal(t, "S1", result.ID)
+	assert.Equal(t, "site", result.Payload.Get("name").String())
+
+	result = NewTaskResult(Res{Raw: `{"status": "SUCCESS", "resultUrl": "/dna/intent/api/v1/dnacaap/management/execution-status/E1/result"}`})
+	assert.Equal(t, "/dna/intent/api/v1/dnacaap/management/execution-status/E1/result", result.ResultURL)
+	assert.False(t, result.Payload.Exists())
+}
+
+// TestClientFollowTaskResult tests the Client::FollowTaskResult method.
+func TestClientFollowTaskResult(t *testing.T) {
+	defer gock.Off()
+	client := authenticatedTestClient()
+
+	gock.New(testURL).Get("/dna/intent/api/v1/file/F1").Reply(200).BodyString(`[{"deviceUuid": "D1"}]`)
+	res, err := client.FollowTaskResult(context.Background(), TaskResult{FileID: "F1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "D1", res.Get("0.deviceUuid").String())
+
+	gock.New(testURL).Get("/api/v1/status/1").Reply(200).BodyString(`{"response": "ok"}`)
+	res, err = client.FollowTaskResult(context.Background(), TaskResult{AdditionalStatusURL: testURL + "/api/v1/status/1", FileID: "F1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Get("response").String())
+
+	gock.New(testURL).Get("/api/v1/execution/E1/result").Reply(200).BodyString(`{"id": "S1"}`)
+	res, err = client.FollowTaskResult(context.Background(), TaskResult{ResultURL: "/api/v1/execution/E1/result", FileID: "F1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "S1", res.Get("id").String())
+
+	_, err = client.FollowTaskResult(context.Background(), TaskResult{AdditionalStatusURL: "https://other/api"})
+	assert.Error(t, err)
+	_, err = client.FollowTaskResult(context.Background(), TaskResult{ResultURL: "https://other/api"})
+	assert.ErrorContains(t, err, "result URL")
+	_, err = client.FollowTaskResult(context.Background(), TaskResult{})
+	assert.Error(t, err)
+	assert.True(t, gock.IsDone())
+}
@@ -0,0 +1,257 @@
+package cc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpanKind is the kind of a span started by a Tracer.
+type SpanKind int
+
+const (
+	// SpanKindInternal is a span of an operation within the client, e.g. a Do call or a backoff wait.
+	SpanKindInternal SpanKind = iota
+	// SpanKindClient is a span of a single HTTP request sent to Catalyst Center.
+	SpanKindClient
+)
+
+// Attr is an attribute of a span. Values are strings, ints or int64s.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// Tracer traces the operations of the client. Every Do call is traced as a span, with child spans for each
+// HTTP attempt, backoff wait, login and task poll. Package otelcc implements it with OpenTelemetry.
+type Tracer interface {
+	// Start starts a span, returning a context carrying it.
+	Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, Span)
+}
+
+// Span is a single operation traced by a Tracer.
+type Span interface {
+	// SetAttributes adds attributes to the span.
+	SetAttributes(attrs ...Attr)
+	// End ends the span, err is the error the operation failed with, if any.
+	End(err error)
+}
+
+// WithTracer enables tracing of the client operations. Tracing is disabled by default.
+func WithTracer(tracer Tracer) func(*Client) {
+	return func(client *Client) {
+		client.tracer = tracer
+	}
+}
+
+// MetricsSink receives measurements of the client activity. Implementations must be safe for concurrent use
+// and should embed NopMetricsSink, so that they keep compiling when methods are added.
+// Package otelcc implements it with OpenTelemetry.
+//
+// Requests are identified by their method and path template, in which IDs are replaced with "{id}".
+type MetricsSink interface {
+	// RequestStarted is called when a request made with Do starts, every call is followed by one of RequestDone.
+	RequestStarted(ctx context.Context, method, template string)
+	// RequestDone is called when a request made with Do completes, including its retries and task wait.
+	// errorType is empty on success, otherwise the status code of an HTTP error or a short classification,
+	// e.g. "task_failed" or "timeout".
+	RequestDone(ctx context.Context, method, template string, duration time.Duration, errorType string)
+	// Retried is called before a failed request attempt is retried.
+	Retried(ctx context.Context, method, template string)
+	// Throttled is called for every response with status 429.
+	Throttled(ctx context.Context, method, template string)
+	// TaskDone is called when a wait for an asynchronous task completes.
+	// outcome is one of "success", "failure", "timeout" or "error".
+	TaskDone(ctx context.Context, kind TaskKind, outcome string, duration time.Duration)
+	// GateWaited is called when a request entered the reader/writer gate of the scope, see WithLockScope.
+	// class is "reader" or "writer".
+	GateWaited(ctx context.Context, scope, class string, wait time.Duration)
+	// PageFetched is called for every page fetched by a paginated GET.
+	PageFetched(ctx context.Context, template string)
+	// ReAuthenticated is called when the client logs in again, reason is "unauthorized" after a 401 response
+	// or "expiring" before the token expires.
+	ReAuthenticated(ctx context.Context, reason string)
+	// RateLimited is called when a request is delayed by a client-side rate limit, see WithRateLimit.
+	RateLimited(ctx context.Context, template string, delay time.Duration)
+}
+
+// NopMetricsSink is a MetricsSink discarding all measurements.
+type NopMetricsSink struct{}
+
+func (NopMetricsSink) RequestStarted(context.Context, string, string)                     {}
+func (NopMetricsSink) RequestDone(context.Context, string, string, time.Duration, string) {}
+func (NopMetricsSink) Retried(context.Context, string, string)                            {}
+func (NopMetricsSink) Throttled(context.Context, string, string)                          {}
+func (NopMetricsSink) TaskDone(context.Context, TaskKind, string, time.Duration)          {}
+func (NopMetricsSink) GateWaited(context.Context, string, string, time.Duration)          {}
+func (NopMetricsSink) PageFetched(context.Context, string)                                {}
+func (NopMetricsSink) ReAuthenticated(context.Context, string)                            {}
+func (NopMetricsSink) RateLimited(context.Context, string, time.Duration)                 {}
+
+// WithMetricsSink reports measurements of the client activity to the sink. Metrics are disabled by default.
+func WithMetricsSink(sink MetricsSink) func(*Client) {
+	return func(client *Client) {
+		client.metrics = sink
+	}
+}
+
+// nopTracer is the Tracer used when tracing is disabled.
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, _ string, _ SpanKind, _ ...Attr) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...Attr) {}
+func (nopSpan) End(error)             {}
+
+// telemetry holds the tracer and metrics sink of a client.
+type telemetry struct {
+	tracer  Tracer
+	metrics MetricsSink
+}
+
+// telemetry returns the tracer and metrics sink of the client, falling back to no-op implementations.
+func (client *Client) telemetry() telemetry {
+	t := telemetry{tracer: client.tracer, metrics: client.metrics}
+	if t.tracer == nil {
+		t.tracer = nopTracer{}
+	}
+	if t.metrics == nil {
+		t.metrics = NopMetricsSink{}
+	}
+	return t
+}
+
+// startRequest starts the span of a request, the returned func ends it and records its duration.
+func (t telemetry) startRequest(ctx context.Context, req *Req) (context.Context, func(error)) {
+	method, template := req.HttpReq.Method, urlTemplate(req.HttpReq.URL.Path)
+	ctx, span := t.tracer.Start(ctx, method+" "+template, SpanKindInternal,
+		Attr{"http.request.method", method}, Attr{"url.template", template})
+	t.metrics.RequestStarted(ctx, method, template)
+	start := time.Now()
+	return ctx, func(err error) {
+		var errType string
+		if err != nil {
+			errType = errorType(err)
+			span.SetAttributes(Attr{"error.type", errType})
+		}
+		t.metrics.RequestDone(ctx, method, template, time.Since(start), errType)
+		span.End(err)
+	}
+}
+
+// startAttempt starts the span of a single HTTP attempt of a request.
+func (t telemetry) startAttempt(ctx context.Context, req *Req, attempt int) (context.Context, Span) {
+	return t.tracer.Start(ctx, req.HttpReq.Method, SpanKindClient,
+		Attr{"http.request.method", req.HttpReq.Method},
+		Attr{"url.full", req.HttpReq.URL.String()},
+		Attr{"http.request.resend_count", attempt})
+}
+
+// endAttempt ends the span of an HTTP attempt and counts throttled responses.
+func (t telemetry) endAttempt(ctx context.Context, span Span, req *Req, statusCode int, err error) {
+	if statusCode != 0 {
+		span.SetAttributes(Attr{"http.response.status_code", statusCode})
+	}
+	if statusCode == 429 {
+		t.metrics.Throttled(ctx, req.HttpReq.Method, urlTemplate(req.HttpReq.URL.Path))
+	}
+	span.End(err)
+}
+
+// backoff waits before a retry of the request, traced as a span.
+func (t telemetry) backoff(ctx context.Context, req *Req, delay time.Duration) error {
+	t.metrics.Retried(ctx, req.HttpReq.Method, urlTemplate(req.HttpReq.URL.Path))
+	ctx, span := t.tracer.Start(ctx, "cc.backoff", SpanKindInternal, Attr{"cc.backoff.delay_ms", delay.Milliseconds()})
+	err := sleep(ctx, delay)
+	span.End(err)
+	return err
+}
+
+// startTask starts the span of a wait for a task, the returned func ends it and records its duration.
+func (t telemetry) startTask(ctx context.Context, kind TaskKind, id string) (context.Context, func(error)) {
+	ctx, span := t.tracer.Start(ctx, "cc.task.wait", SpanKindInternal,
+		Attr{"cc.task.kind", string(kind)}, Attr{"cc.task.id", id})
+	start := time.Now()
+	return ctx, func(err error) {
+		outcome := "success"
+		var taskErr *TaskError
+		var timeoutErr *TimeoutError
+		switch {
+		case errors.As(err, &taskErr):
+			outcome = "failure"
+		case errors.As(err, &timeoutErr):
+			outcome = "timeout"
+		case err != nil:
+			outcome = "error"
+		}
+		t.metrics.TaskDone(ctx, kind, outcome, time.Since(start))
+		span.End(err)
+	}
+}
+
+// startPoll starts the span of a single task status poll.
+func (t telemetry) startPoll(ctx context.Context, kind TaskKind, id string, poll int) (context.Context, Span) {
+	return t.tracer.Start(ctx, "cc.task.poll", SpanKindClient,
+		Attr{"cc.task.kind", string(kind)}, Attr{"cc.task.id", id}, Attr{"cc.task.poll", poll})
+}
+
+// errorType classifies an error for the "error.type" attribute.
+func errorType(err error) string {
+	var httpErr *HTTPError
+	var taskErr *TaskError
+	var timeoutErr *TimeoutError
+	var authErr *AuthError
+	switch {
+	case errors.As(err, &httpErr):
+		return strconv.Itoa(httpErr.StatusCode)
+	case errors.As(err, &taskErr):
+		return "task_failed"
+	case errors.As(err, &timeoutErr):
+		return "timeout"
+	case errors.Is(err, ErrClientClosed):
+		return "client_closed"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	case errors.As(err, &authErr):
+		return "authentication_failed"
+	}
+	return "_OTHER"
+}
+
+// urlTemplate replaces the segments of the path that look like IDs, i.e. UUIDs, numbers and
+// long hexadecimal strings, with "{id}", e.g. "/dna/intent/api/v1/network-device/{id}".
+func urlTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if isID(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isID reports whether the path segment looks like an ID.
+func isID(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	digits := true
+	for _, c := range segment {
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'a' && c <= 'f', c >= 'A' && c <= 'F', c == '-':
+			digits = false
+		default:
+			return false
+		}
+	}
+	return digits || len(segment) >= 16
+}
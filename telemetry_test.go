@@ -0,0 +1,103 @@
+package cc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// recordingTracer is a Tracer recording the names of ended spans.
+type recordingTracer struct {
+	mu    sync.Mutex
+	ended []string
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, _ SpanKind, _ ...Attr) (context.Context, Span) {
+	return ctx, &recordingSpan{tracer: t, name: name}
+}
+
+type recordingSpan struct {
+	tracer *recordingTracer
+	name   string
+}
+
+func (s *recordingSpan) SetAttributes(...Attr) {}
+
+func (s *recordingSpan) End(error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.ended = append(s.tracer.ended, s.name)
+}
+
+// recordingMetricsSink is a MetricsSink recording request and task outcomes.
+type recordingMetricsSink struct {
+	NopMetricsSink
+	requests []string
+	tasks    []string
+	pages    []string
+	gates    []string
+}
+
+func (m *recordingMetricsSink) RequestDone(_ context.Context, method, template string, _ time.Duration, errorType string) {
+	m.requests = append(m.requests, method+" "+template+" "+errorType)
+}
+
+func (m *recordingMetricsSink) TaskDone(_ context.Context, kind TaskKind, outcome string, _ time.Duration) {
+	m.tasks = append(m.tasks, string(kind)+" "+outcome)
+}
+
+func (m *recordingMetricsSink) PageFetched(_ context.Context, template string) {
+	m.pages = append(m.pages, template)
+}
+
+func (m *recordingMetricsSink) GateWaited(_ context.Context, scope, class string, _ time.Duration) {
+	m.gates = append(m.gates, scope+" "+class)
+}
+
+// TestUrlTemplate tests the urlTemplate function.
+func TestUrlTemplate(t *testing.T) {
+	assert.Equal(t, "/dna/intent/api/v1/network-device/{id}", urlTemplate("/dna/intent/api/v1/network-device/4bb2d5f6-5c4c-4e0a-9f3c-2d3e4f5a6b7c"))
+	assert.Equal(t, "/api/v1/task/{id}/tree", urlTemplate("/api/v1/task/123/tree"))
+	assert.Equal(t, "/dna/intent/api/v1/site/{id}", urlTemplate("/dna/intent/api/v1/site/5f1a2b3c4d5e6f7a8b9c0d1e"))
+	assert.Equal(t, "/dna/intent/api/v1/global-pool", urlTemplate("/dna/intent/api/v1/global-pool"))
+}
+
+// TestClientTelemetry tests the spans and measurements of a request.
+func TestClientTelemetry(t *testing.T) {
+	defer gock.Off()
+	tracer := &recordingTracer{}
+	metrics := &recordingMetricsSink{}
+	client := authenticatedTestClient()
+	WithTracer(tracer)(client)
+	WithMetricsSink(metrics)(client)
+
+	gock.New(testURL).Post("/url").Reply(202).BodyString(`{"response": {"taskId": "123"}}`)
+	gock.New(testURL).Get("/api/v1/task/123").Reply(200).BodyString(`{"response": {"isError": true, "failureReason": "bad"}}`)
+
+	_, err := client.Post("/url", "{}")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"POST", "cc.task.poll", "cc.task.wait", "POST /url"}, tracer.ended)
+	assert.Equal(t, []string{"POST /url task_failed"}, metrics.requests)
+	assert.Equal(t, []string{"task failure"}, metrics.tasks)
+}
+
+// TestClientMetricsSinkPages tests the measurements of a paginated GET.
+func TestClientMetricsSinkPages(t *testing.T) {
+	defer gock.Off()
+	metrics := &recordingMetricsSink{}
+	client := authenticatedTestClient()
+	WithMetricsSink(metrics)(client)
+
+	gock.New(testURL).Get("/url").MatchParam("offset", "3").Reply(200).BodyString(`{"response": [3]}`)
+	gock.New(testURL).Get("/url").Reply(200).BodyString(`{"response": [1, 2], "totalCount": 3}`)
+
+	res, err := client.Get("/url", PageSize(2))
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,2,3]", res.Get("response").Raw)
+	assert.Equal(t, []string{"/url", "/url"}, metrics.pages)
+	assert.Equal(t, []string{" reader"}, metrics.gates)
+}
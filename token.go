@@ -0,0 +1,107 @@
+package cc
+
+import (
+	"encoding/base64"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// DefaultTokenLifetime is the lifetime of Catalyst Center tokens, unless the token itself contains an expiry.
+const DefaultTokenLifetime = 60 * time.Minute
+
+// DefaultTokenRefreshMargin is how long before its expiry a token is refreshed.
+const DefaultTokenRefreshMargin = 5 * time.Minute
+
+// TokenLifetime modifies the assumed token lifetime from the default of 60 minutes.
+// It is only used if the token does not contain an expiry ("exp" claim).
+func TokenLifetime(x time.Duration) func(*Client) {
+	return func(client *Client) {
+		client.TokenLifetime = x
+	}
+}
+
+// TokenRefreshMargin modifies how long before its expiry a token is refreshed from the default of 5 minutes.
+func TokenRefreshMargin(x time.Duration) func(*Client) {
+	return func(client *Client) {
+		client.TokenRefreshMargin = x
+	}
+}
+
+// Token returns the current authentication token.
+func (client *Client) Token() string {
+	client.token.mu.RLock()
+	defer client.token.mu.RUnlock()
+	return client.token.value
+}
+
+// SetToken sets the authentication token, e.g. one obtained outside of the client.
+// The expiry of such token is unknown, unless it contains an expiry ("exp" claim).
+func (client *Client) SetToken(token string) {
+	client.token.mu.Lock()
+	defer client.token.mu.Unlock()
+	client.token.value = token
+	client.token.expiresAt, _ = jwtExpiry(token)
+}
+
+// TokenExpiresAt returns the expiry time of the current token.
+// It returns the zero time if there is no token or its expiry is unknown.
+func (client *Client) TokenExpiresAt() time.Time {
+	client.token.mu.RLock()
+	defer client.token.mu.RUnlock()
+	return client.token.expiresAt
+}
+
+// tokenExpiring reports whether the current token expires within the refresh margin.
+func (client *Client) tokenExpiring() bool {
+	expiresAt := client.TokenExpiresAt()
+	return !expiresAt.IsZero() && time.Until(expiresAt) < client.TokenRefreshMargin
+}
+
+// setToken sets a new token obtained by Login and its expiry.
+func (client *Client) setToken(token string, issuedAt time.Time) {
+	client.token.mu.Lock()
+	defer client.token.mu.Unlock()
+	client.token.value = token
+	client.token.expiresAt = issuedAt.Add(client.TokenLifetime)
+	if exp, ok := jwtExpiry(token); ok {
+		client.token.expiresAt = exp
+	}
+}
+
+// invalidateToken clears the token after it was rejected with a 401.
+// The token is only cleared if it was not already replaced by another goroutine.
+func (client *Client) invalidateToken(token string) {
+	client.token.mu.Lock()
+	defer client.token.mu.Unlock()
+	if client.token.value == token {
+		client.token.value = ""
+		client.token.expiresAt = time.Time{}
+	}
+}
+
+// tokenState is the current token and its expiry, shared by all goroutines using the client.
+type tokenState struct {
+	mu        sync.RWMutex
+	value     string
+	expiresAt time.Time
+}
+
+// jwtExpiry returns the "exp" claim of a JWT token.
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	exp := gjson.GetBytes(payload, "exp")
+	if exp.Type != gjson.Number {
+		return time.Time{}, false
+	}
+	return time.Unix(exp.Int(), 0), true
+}
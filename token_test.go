@@ -0,0 +1,53 @@
+package cc
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+)
+
+// TestClientTokenExpiresAt tests the Client.TokenExpiresAt method.
+func TestClientTokenExpiresAt(t *testing.T) {
+	defer gock.Off()
+	client := testClient()
+	assert.True(t, client.TokenExpiresAt().IsZero())
+
+	// Token without expiry claim
+	gock.New(testURL).Post("/dna/system/api/v1/auth/token").Reply(200).BodyString(`{"Token": "ABC"}`)
+	assert.NoError(t, client.Login())
+	assert.WithinDuration(t, time.Now().Add(DefaultTokenLifetime), client.TokenExpiresAt(), time.Minute)
+
+	// JWT token with expiry claim
+	exp := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	jwt := "e30." + base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix()))) + ".sig"
+	gock.New(testURL).Post("/dna/system/api/v1/auth/token").Reply(200).BodyString(`{"Token": "` + jwt + `"}`)
+	assert.NoError(t, client.Login())
+	assert.Equal(t, exp, client.TokenExpiresAt())
+
+	// Token set manually
+	client.SetToken("XYZ")
+	assert.True(t, client.TokenExpiresAt().IsZero())
+}
+
+// TestClientTokenRefresh tests the proactive refresh of a token about to expire.
+func TestClientTokenRefresh(t *testing.T) {
+	defer gock.Off()
+	client := testClient()
+
+	gock.New(testURL).Post("/dna/system/api/v1/auth/token").Reply(200).BodyString(`{"Token": "ABC"}`)
+	gock.New(testURL).Get("/url").MatchHeader("X-Auth-Token", "ABC").Reply(200)
+	_, err := client.Get("/url")
+	assert.NoError(t, err)
+
+	// The token expires within the refresh margin, so it is refreshed before the request.
+	client.token.expiresAt = time.Now().Add(time.Minute)
+	gock.New(testURL).Post("/dna/system/api/v1/auth/token").Reply(200).BodyString(`{"Token": "DEF"}`)
+	gock.New(testURL).Get("/url").MatchHeader("X-Auth-Token", "DEF").Reply(200)
+	_, err = client.Get("/url")
+	assert.NoError(t, err)
+	assert.True(t, gock.IsDone())
+}
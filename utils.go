@@ -1,5 +1,10 @@
 package cc
 
+import (
+	"context"
+	"time"
+)
+
 func contains(s []string, str string) bool {
 	for _, v := range s {
 		if v == str {
@@ -8,3 +13,18 @@ func contains(s []string, str string) bool {
 	}
 	return false
 }
+
+// sleep pauses for the given duration or until the context is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}